@@ -1,27 +1,108 @@
 package article
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/leonsteinhaeuser/example-app/internal/blob"
 	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/keystore"
 	"github.com/leonsteinhaeuser/example-app/internal/log"
+	"github.com/leonsteinhaeuser/example-app/internal/pubsub"
 	"github.com/leonsteinhaeuser/example-app/internal/server"
 	"github.com/leonsteinhaeuser/example-app/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	headerTotalCount = "X-Total-Count"
+)
+
+var (
+	errArticleNotFound = fmt.Errorf("article %w", db.ErrNotFound)
 )
 
 type articleRouter struct {
 	log log.Logger
 
 	db db.Repository
+
+	// searchLanguage is the text search configuration used for full-text search.
+	searchLanguage string
+	// requireIfMatch rejects modifications without If-Match header.
+	requireIfMatch bool
+	// publisher receives an event after each change of an article. It is optional.
+	publisher pubsub.Publisher
+	// cache holds articles and list responses for cacheTTL. It is optional.
+	cache    keystore.KeyStore
+	cacheTTL time.Duration
+	// feedTitle is the title of the feeds, feedArticleURL the URL the feed entries link to.
+	feedTitle      string
+	feedArticleURL string
+	// attachments stores the files uploaded to articles. Uploads are disabled if it is nil.
+	attachments       blob.Store
+	maxAttachmentSize int64
+}
+
+// Option configures optional settings of the article router.
+type Option func(*articleRouter)
+
+// WithSearchLanguage sets the text search configuration used for full-text search, e.g. "english" or "german".
+// The search index is rebuilt by Migrate if the language changes.
+func WithSearchLanguage(language string) Option {
+	return func(t *articleRouter) {
+		t.searchLanguage = language
+	}
+}
+
+// WithRequireIfMatch rejects updates and deletions of articles without If-Match header
+// with 428 Precondition Required, so clients cannot overwrite changes they have not seen.
+func WithRequireIfMatch(require bool) Option {
+	return func(t *articleRouter) {
+		t.requireIfMatch = require
+	}
 }
 
-func NewArticleRouter(log log.Logger, db db.Repository) *articleRouter {
-	return &articleRouter{
-		log: log,
-		db:  db,
+func NewArticleRouter(log log.Logger, db db.Repository, options ...Option) *articleRouter {
+	rt := &articleRouter{
+		log:            log,
+		db:             db,
+		searchLanguage: DefaultSearchLanguage,
+		feedTitle:      DefaultFeedTitle,
+	}
+	for _, option := range options {
+		option(rt)
 	}
+	return rt
+}
+
+// Migrate creates or updates the database schema used by the router.
+func (t *articleRouter) Migrate(ctx context.Context) error {
+	for _, model := range []any{&Author{}, &Article{}, &ArticleRevision{}, &ArticleSlug{}, &Comment{}, &Attachment{}} {
+		err := t.db.Migrate(ctx, model)
+		if err != nil {
+			return err
+		}
+	}
+
+	statements, err := searchMigrations(t.searchLanguage)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range statements {
+		err = t.db.Raw(ctx, stmt)
+		if err != nil {
+			return err
+		}
+	}
+	return t.backfillSlugs(ctx)
 }
 
 func (t *articleRouter) Router(rt chi.Router) {
@@ -29,11 +110,73 @@ func (t *articleRouter) Router(rt chi.Router) {
 		rt.Route("/{id}", func(rt chi.Router) {
 			rt.Get("/", t.getArticle)
 			rt.Put("/", t.updateArticle)
+			rt.Patch("/", t.patchArticle)
 			rt.Delete("/", t.deleteArticle)
+			rt.Get("/rendered", t.getRenderedArticle)
+			rt.Post("/publish", t.publishArticle)
+			rt.Post("/unpublish", t.unpublishArticle)
+			rt.Post("/restore", t.restoreArticle)
+			rt.Route("/revisions", func(rt chi.Router) {
+				rt.Get("/", t.getRevisions)
+				rt.Get("/{revision}", t.getRevision)
+				rt.Get("/{revision}/diff", t.diffRevision)
+				rt.Post("/{revision}/restore", t.restoreRevision)
+			})
+			// comments are written and moderated by the user of the request, which is only known
+			// if the server trusts the proxy setting the user header, see server.WithTrustedProxies
+			rt.Route("/comments", func(rt chi.Router) {
+				rt.Get("/", t.getComments)
+				rt.Post("/", t.createComment)
+				rt.Get("/{comment}", t.getComment)
+				rt.Put("/{comment}", t.updateComment)
+				rt.Delete("/{comment}", t.deleteComment)
+				rt.Post("/{comment}/moderate", t.moderateComment)
+			})
+			if t.attachments != nil {
+				rt.Route("/attachments", func(rt chi.Router) {
+					rt.Get("/", t.getAttachments)
+					rt.Post("/", t.uploadAttachment)
+					rt.Get("/{attachment}", t.getAttachment)
+					rt.Get("/{attachment}/content", t.getAttachmentContent)
+					rt.Delete("/{attachment}", t.deleteAttachment)
+				})
+			}
 		})
+		rt.Get("/by-slug/{slug}", t.getArticleBySlug)
+		rt.Get("/search", t.searchArticles)
+		rt.Get("/trash", t.getTrash)
+		rt.Get("/export", t.exportArticles)
+		rt.Post("/import", t.importArticles)
 		rt.Get("/", t.getArticles)
 		rt.Post("/", t.createArticle)
 	})
+	rt.Post("/articles:batch", t.batchArticles)
+	rt.Route("/authors", func(rt chi.Router) {
+		rt.Get("/", t.getAuthors)
+		rt.Post("/", t.createAuthor)
+		rt.Get("/{id}", t.getAuthor)
+		rt.Put("/{id}", t.updateAuthor)
+		rt.Delete("/{id}", t.deleteAuthor)
+		rt.Get("/{id}/articles", t.getAuthorArticles)
+	})
+	rt.Route("/feeds", func(rt chi.Router) {
+		rt.Get("/atom.xml", t.getAtomFeed)
+		rt.Get("/rss.xml", t.getRSSFeed)
+	})
+	rt.Route("/tags", func(rt chi.Router) {
+		rt.Get("/", t.getTags)
+		rt.Post("/{tag}/rename", t.renameTag)
+	})
+}
+
+// writeError writes the response of a request that failed with err. The status is determined by server.StatusCode.
+// Unexpected errors are logged, since they are not caused by the client.
+func (t *articleRouter) writeError(w http.ResponseWriter, message string, err error) {
+	e := server.NewError(message, err)
+	if e.Status == http.StatusInternalServerError {
+		t.log.Error(err).Log(message)
+	}
+	utils.WriteJSON(w, e.Status, e)
 }
 
 func (t *articleRouter) createArticle(w http.ResponseWriter, r *http.Request) {
@@ -50,98 +193,372 @@ func (t *articleRouter) createArticle(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	err = article.Validate()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, server.NewValidationError("invalid article", err))
+		return
+	}
 
-	err = t.db.Create(ctx, article)
+	err = t.insertArticle(ctx, article)
 	if err != nil {
-		t.log.Error(err).Log("failed to create article")
-		utils.WriteJSON(w, http.StatusInternalServerError, server.Error{
-			Status:  http.StatusInternalServerError,
-			Message: "failed to create article",
-			Error:   err.Error(),
-		})
+		t.writeError(w, "failed to create article", err)
 		return
 	}
 
+	t.articleChanged(pubsub.ActionTypeCreate, article)
+	w.Header().Set("ETag", articleETag(article))
 	utils.WriteJSON(w, http.StatusCreated, article)
 }
 
-// getArticles returns a list of articles.
+const (
+	// defaultListLimit is the page size used if a list request does not specify a limit.
+	defaultListLimit = 50
+	// maxListLimit is the largest page size a list request may ask for.
+	maxListLimit = 500
+)
+
+// articleOrderFields maps the fields articles can be sorted by to their cursor value.
+var articleOrderFields = map[string]func(*Article) any{
+	"id":           func(a *Article) any { return a.ID },
+	"created_at":   func(a *Article) any { return a.CreatedAt },
+	"updated_at":   func(a *Article) any { return a.UpdatedAt },
+	"published_at": func(a *Article) any { return a.PublishedAt },
+	"title":        func(a *Article) any { return a.Title },
+	"version":      func(a *Article) any { return a.Version },
+}
+
+// getArticles returns a page of articles.
 // Optional query parameters:
 // - published: bool
 // - author_id: uuid
-// - limit: int
+// - co_author_id: uuid
+// - contributor_id: uuid, matches the author and the co-authors
+// - limit: int (default: 50, max: 500)
 // - published_before: timestamp
 // - published_after: timestamp
+// - tags: comma separated list of tags
+// - match: any (default) or all, whether articles must have any or all of the tags
+// - sort: comma separated list of field:direction, e.g. published_at:desc,title:asc (default: created_at:desc)
+// - order_by: created_at or published_at, alias of sort=<order_by>:<order>
+// - order: desc (default) or asc, used with order_by
+// - cursor: next_cursor or prev_cursor of a previous response
+// - count: bool, adds the total number of matching articles as X-Total-Count header
+// - render: html, adds the content rendered to HTML as content_html, includes content and content_html by default
+// - fields: comma separated list of the fields of the articles to return, e.g. id,title,published_at
+// - exclude: comma separated list of the fields of the articles to leave out (default: content and derived fields)
+// - expand: comma separated list of author and co_authors, embeds the profiles of the authors
 func (t *articleRouter) getArticles(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	query := r.URL.Query()
 
-	articles := &[]*Article{}
-	dbtx := t.db.Find(articles)
+	t.log.Debug().Field("query", query).Log("query")
 
-	t.log.Debug().Field("query", r.URL.Query()).Log("query")
+	keyset, err := articleKeyset(query)
+	if err != nil {
+		t.log.Error(err).Log("invalid order")
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid order",
+			Error:   err.Error(),
+		})
+		return
+	}
 
-	// apply optional filters to transaction
+	limit := defaultListLimit
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
 
+	var cursor *db.Cursor
+	if c := query.Get("cursor"); c != "" {
+		crsr, err := db.DecodeCursor(c, keyset)
+		if err != nil {
+			t.log.Error(err).Log("invalid cursor")
+			utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+				Status:  http.StatusBadRequest,
+				Message: "invalid cursor",
+				Error:   err.Error(),
+			})
+			return
+		}
+		cursor = &crsr
+	}
+
+	fields, err := parseListFieldSet(query)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid fields",
+			Error:   err.Error(),
+		})
+		return
+	}
+	expand, err := parseExpansion(query)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid expand",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	key := t.listCacheKey(ctx, query)
+	page := &articlePage{}
+	if !t.cacheGet(ctx, key, page) {
+		page, err = t.listArticles(ctx, query, fields, keyset, limit, cursor)
+		if err != nil {
+			t.writeError(w, "failed to list articles", err)
+			return
+		}
+		t.cacheSet(ctx, key, page)
+	}
+	if page.Total != nil {
+		w.Header().Set(headerTotalCount, strconv.FormatInt(*page.Total, 10))
+	}
+
+	rendered := page.List.Items
+	if !fields.Has("content_html") {
+		// the content is not read if content_html is not included, so there is nothing to render
+		rendered = nil
+	}
+	if !t.writeRendered(w, r, rendered...) {
+		return
+	}
+	// the profiles are embedded after caching the page, so that changes of the authors are visible immediately
+	err = expandArticles(ctx, t.db, expand, page.List.Items...)
+	if err != nil {
+		t.writeError(w, "failed to list articles", err)
+		return
+	}
+
+	t.log.Debug().Field("articles", page.List.Items).Log("articles")
+
+	list, err := fields.ProjectList(page.List)
+	if err != nil {
+		t.writeError(w, "failed to list articles", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// listArticles reads the page of articles matching query that follows cursor, or the first page if cursor is nil.
+// Only the columns needed for fields are read.
+func (t *articleRouter) listArticles(ctx context.Context, query url.Values, fields fieldSet, keyset db.Keyset, limit int, cursor *db.Cursor) (*articlePage, error) {
+	backward := cursor != nil && cursor.Backward
+
+	articles := []*Article{}
+	dbtx := fields.Select(filterArticles(t.db.Find(&articles), query), keyset)
+	if keyset.Has("published_at") {
+		// unpublished articles have no position in this order
+		dbtx = dbtx.Where("published_at IS NOT NULL")
+	}
+	if cursor != nil {
+		dbtx = keyset.After(dbtx, cursor.Values, backward)
+	}
+	// fetch one additional article to find out whether there is another page
+	err := keyset.Order(dbtx, backward).Limit(limit + 1).Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(articles) > limit
+	if hasMore {
+		articles = articles[:limit]
+	}
+	if backward {
+		// a backward page was read in reverse order
+		for i, j := 0, len(articles)-1; i < j; i, j = i+1, j-1 {
+			articles[i], articles[j] = articles[j], articles[i]
+		}
+	}
+
+	page := &articlePage{
+		List: ArticleList{
+			Items: articles,
+		},
+	}
+	if len(articles) > 0 {
+		// there is a next page if more articles were found going forward or if we came from it going backward
+		if hasMore || backward {
+			page.List.NextCursor = articleCursor(articles[len(articles)-1], keyset, false)
+		}
+		// there is a previous page if more articles were found going backward or if we came from it going forward
+		if (backward && hasMore) || (!backward && cursor != nil) {
+			page.List.PrevCursor = articleCursor(articles[0], keyset, true)
+		}
+	}
+
+	if count, _ := strconv.ParseBool(query.Get("count")); count {
+		total := int64(0)
+		countTx := filterArticles(t.db.Count(&Article{}, &total), query)
+		if keyset.Has("published_at") {
+			countTx = countTx.Where("published_at IS NOT NULL")
+		}
+		err = countTx.Commit(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count articles: %w", err)
+		}
+		page.Total = &total
+	}
+	return page, nil
+}
+
+// filterArticles applies the optional filters of a list request to dbtx.
+func filterArticles(dbtx db.TX, query url.Values) db.TX {
 	// filter by "published"
-	if publshd, err := strconv.ParseBool(r.URL.Query().Get("published")); err == nil {
+	if publshd, err := strconv.ParseBool(query.Get("published")); err == nil {
 		dbtx = dbtx.Where("published = ?", publshd)
 	}
 	// filter by "author"
-	if author := r.URL.Query().Get("author_id"); author != "" {
+	if author := query.Get("author_id"); author != "" {
 		dbtx = dbtx.Where("author_id = ?", author)
 	}
-	// filter by "limit"
-	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
-		dbtx = dbtx.Limit(limit)
+	// filter by "co_author_id"
+	if coAuthor := query.Get("co_author_id"); coAuthor != "" {
+		dbtx = dbtx.Where("co_author_ids @> ?::jsonb", jsonArray(coAuthor))
+	}
+	// filter by "contributor_id", which is either the author or a co-author
+	if contributor := query.Get("contributor_id"); contributor != "" {
+		dbtx = dbtx.Where("(author_id = ? OR co_author_ids @> ?::jsonb)", contributor, jsonArray(contributor))
+	}
+	// filter by "tags"
+	if tags := query.Get("tags"); tags != "" {
+		dbtx = filterTags(dbtx, strings.Split(tags, ","), query.Get("match") == "all")
 	}
 	// filter by published_before
-	if publishedBefore := r.URL.Query().Get("published_before"); publishedBefore != "" {
+	if publishedBefore := query.Get("published_before"); publishedBefore != "" {
 		dbtx = dbtx.Where("published_at < ?", publishedBefore)
 	}
 	// filter by published_after
-	if publishedAfter := r.URL.Query().Get("published_after"); publishedAfter != "" {
+	if publishedAfter := query.Get("published_after"); publishedAfter != "" {
 		dbtx = dbtx.Where("published_at > ?", publishedAfter)
 	}
+	return dbtx
+}
 
-	err := dbtx.Commit(ctx)
-	if err != nil {
-		t.log.Error(err).Log("failed to list articles")
-		utils.WriteJSON(w, http.StatusInternalServerError, server.Error{
-			Status:  http.StatusInternalServerError,
-			Message: "failed to list articles",
-			Error:   err.Error(),
-		})
-		return
+// articleKeyset returns the order of a list request.
+// The article ID is always used as the last field to make the order stable.
+func articleKeyset(query url.Values) (db.Keyset, error) {
+	sort := query.Get("sort")
+	if sort == "" {
+		// order_by and order are the previous form of sort
+		sort = "created_at:desc"
+		if orderBy := query.Get("order_by"); orderBy != "" {
+			sort = orderBy + ":desc"
+		}
+		switch order := query.Get("order"); order {
+		case "", "desc":
+		case "asc":
+			sort = strings.TrimSuffix(sort, ":desc") + ":asc"
+		default:
+			return nil, fmt.Errorf("unknown order %q, expected asc or desc", order)
+		}
 	}
 
-	t.log.Debug().Field("articles", articles).Log("articles")
+	keyset, err := db.ParseKeyset(sort)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range keyset {
+		if _, ok := articleOrderFields[f.Column]; !ok {
+			return nil, fmt.Errorf("articles cannot be sorted by %q", f.Column)
+		}
+	}
+	if !keyset.Has("id") {
+		keyset = append(keyset, db.OrderField{Column: "id", Desc: keyset[0].Desc})
+	}
+	return keyset, nil
+}
 
-	utils.WriteJSON(w, http.StatusOK, articles)
+// articleCursor returns the encoded cursor pointing at article.
+func articleCursor(article *Article, keyset db.Keyset, backward bool) string {
+	values := make([]any, 0, len(keyset))
+	for _, f := range keyset {
+		values = append(values, articleOrderFields[f.Column](article))
+	}
+	return db.Cursor{
+		Keyset:   keyset.String(),
+		Values:   values,
+		Backward: backward,
+	}.Encode()
 }
 
+// getArticle returns the article with the given ID.
+// The query parameters render, fields, exclude and expand are accepted as for getArticles,
+// but all fields are returned by default.
 func (t *articleRouter) getArticle(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	id := chi.URLParam(r, "id")
 
-	article := &Article{}
-	err := t.db.Find(article).Where("id = ?", id).Commit(ctx)
+	article, err := t.findCachedArticle(ctx, id)
 	if err != nil {
-		t.log.Error(err).Log("failed to get article")
-		utils.WriteJSON(w, http.StatusInternalServerError, server.Error{
-			Status:  http.StatusInternalServerError,
-			Message: "failed to get article",
+		t.writeError(w, "failed to get article", err)
+		return
+	}
+
+	t.writeArticle(w, r, article)
+}
+
+// writeArticle writes article as response to a read request.
+// The article is not written if the client has a current copy. The ETag only covers the article,
+// not the embedded author profiles.
+func (t *articleRouter) writeArticle(w http.ResponseWriter, r *http.Request, article *Article) {
+	etag := articleETag(article)
+	w.Header().Set("ETag", etag)
+	// clients may keep the article, but have to revalidate it before use
+	w.Header().Set("Cache-Control", "no-cache")
+	if server.ETagMatches(r.Header.Get("If-None-Match"), etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	fields, err := parseFieldSet(r.URL.Query())
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid fields",
 			Error:   err.Error(),
 		})
 		return
 	}
+	expand, err := parseExpansion(r.URL.Query())
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid expand",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if !t.writeRendered(w, r, article) {
+		return
+	}
+	err = expandArticles(r.Context(), t.db, expand, article)
+	if err != nil {
+		t.writeError(w, "failed to get article", err)
+		return
+	}
 
-	utils.WriteJSON(w, http.StatusOK, article)
+	projected, err := fields.Project(article)
+	if err != nil {
+		t.writeError(w, "failed to get article", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, projected)
 }
 
+// updateArticle replaces an article with the request body.
 func (t *articleRouter) updateArticle(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	id := chi.URLParam(r, "id")
 
+	if t.ifMatchMissing(w, r) {
+		return
+	}
+
 	article := &Article{}
 	err := utils.ReadJSON(r, article)
 	if err != nil {
@@ -153,18 +570,31 @@ func (t *articleRouter) updateArticle(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-
-	err = t.db.Update(article).Where("id = ?", id).Commit(ctx)
+	err = article.Validate()
 	if err != nil {
-		t.log.Error(err).Log("failed to update article")
-		utils.WriteJSON(w, http.StatusInternalServerError, server.Error{
-			Status:  http.StatusInternalServerError,
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, server.NewValidationError("invalid article", err))
+		return
+	}
+
+	// PUT replaces all writable fields, so omitted fields are reset to their zero value
+	updated, err := t.replaceArticle(ctx, id, r.Header.Get("If-Match"), func(db.Repository, *Article) (*Article, error) {
+		return article, nil
+	})
+	if errors.Is(err, errPreconditionFailed) {
+		utils.WriteJSON(w, http.StatusPreconditionFailed, server.Error{
+			Status:  http.StatusPreconditionFailed,
 			Message: "failed to update article",
 			Error:   err.Error(),
 		})
 		return
 	}
+	if err != nil {
+		t.writeError(w, "failed to update article", err)
+		return
+	}
 
+	t.articleChanged(pubsub.ActionTypeUpdate, updated)
+	w.Header().Set("ETag", articleETag(updated))
 	utils.WriteJSON(w, http.StatusNoContent, map[string]any{})
 }
 
@@ -172,16 +602,157 @@ func (t *articleRouter) deleteArticle(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	id := chi.URLParam(r, "id")
 
-	err := t.db.Delete(&Article{}).Where("id = ?", id).Commit(ctx)
-	if err != nil {
-		t.log.Error(err).Log("failed to delete article")
-		utils.WriteJSON(w, http.StatusInternalServerError, server.Error{
-			Status:  http.StatusInternalServerError,
+	if t.ifMatchMissing(w, r) {
+		return
+	}
+
+	deleted, err := t.removeArticle(ctx, id, r.Header.Get("If-Match"))
+	if errors.Is(err, errPreconditionFailed) {
+		utils.WriteJSON(w, http.StatusPreconditionFailed, server.Error{
+			Status:  http.StatusPreconditionFailed,
 			Message: "failed to delete article",
 			Error:   err.Error(),
 		})
 		return
 	}
+	if err != nil {
+		t.writeError(w, "failed to delete article", err)
+		return
+	}
 
+	t.articleChanged(pubsub.ActionTypeDelete, deleted)
 	utils.WriteJSON(w, http.StatusNoContent, map[string]any{})
 }
+
+// insertArticle stores a new article and records its first revision.
+func (t *articleRouter) insertArticle(ctx context.Context, article *Article) error {
+	article.Version = 1
+	article.Tags = normalizeTags(article.Tags)
+	// articles are moved to the trash by deleting them, not by creating them there
+	article.DeletedAt = gorm.DeletedAt{}
+	return t.db.Transaction(ctx, func(tx db.Repository) error {
+		err := checkAuthors(ctx, tx, article, nil)
+		if err != nil {
+			return err
+		}
+		err = storeWithSlug(ctx, tx, article, nil, func(tx db.Repository) error {
+			return tx.Create(ctx, article)
+		})
+		if err != nil {
+			return err
+		}
+		return t.createRevision(ctx, tx, article, nil)
+	})
+}
+
+// removeArticle moves the article with the given ID to the trash and returns it.
+// If ifMatch is set, it must match the ETag of the article.
+// Removing a missing article fails with errArticleNotFound, or errPreconditionFailed if ifMatch is set.
+func (t *articleRouter) removeArticle(ctx context.Context, id string, ifMatch string) (*Article, error) {
+	var deleted *Article
+	err := t.db.Transaction(ctx, func(tx db.Repository) error {
+		current := []*Article{}
+		err := tx.Find(&current).Where("id = ?", id).Limit(1).ForUpdate(false).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		if len(current) == 0 {
+			// a precondition on a missing article cannot be met
+			if ifMatch != "" {
+				return errPreconditionFailed
+			}
+			return errArticleNotFound
+		}
+		err = checkIfMatch(ifMatch, current[0])
+		if err != nil {
+			return err
+		}
+
+		// the article is moved to the trash, its revisions are kept until it is purged
+		err = tx.Delete(&Article{}).Where("id = ?", id).RequireMatch().Commit(ctx)
+		if err != nil {
+			return err
+		}
+		deleted = current[0]
+		return nil
+	})
+	return deleted, err
+}
+
+// findArticle returns the article with the given ID.
+func findArticle(ctx context.Context, tx db.Repository, id string) (*Article, error) {
+	articles := []*Article{}
+	err := tx.Find(&articles).Where("id = ?", id).Limit(1).Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(articles) == 0 {
+		return nil, errArticleNotFound
+	}
+	return articles[0], nil
+}
+
+// replaceArticle overwrites the writable fields of the article with the given ID with the article returned by replace.
+// replace receives the transaction and the current state of the article, which is locked until the replacement is stored.
+// If ifMatch is set, it must match the ETag of the current article.
+// A revision is recorded and the stored article is returned.
+// Replacing a missing article fails with errArticleNotFound, or errPreconditionFailed if ifMatch is set.
+func (t *articleRouter) replaceArticle(ctx context.Context, id string, ifMatch string, replace func(tx db.Repository, current *Article) (*Article, error)) (*Article, error) {
+	var updated *Article
+	err := t.db.Transaction(ctx, func(tx db.Repository) error {
+		current := []*Article{}
+		err := tx.Find(&current).Where("id = ?", id).Limit(1).ForUpdate(false).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		if len(current) == 0 {
+			// a precondition on a missing article cannot be met
+			if ifMatch != "" {
+				return errPreconditionFailed
+			}
+			return errArticleNotFound
+		}
+
+		err = checkIfMatch(ifMatch, current[0])
+		if err != nil {
+			return err
+		}
+
+		// replace may modify the article it receives, so it gets a copy
+		previous := *current[0]
+		article, err := replace(tx, current[0])
+		if err != nil {
+			return err
+		}
+		// the article is identified by the URL, not by the body
+		article.ID = previous.ID
+		article.Version = previous.Version + 1
+		article.Tags = normalizeTags(article.Tags)
+		err = checkAuthors(ctx, tx, article, &previous)
+		if err != nil {
+			return err
+		}
+		err = storeWithSlug(ctx, tx, article, &previous, func(tx db.Repository) error {
+			return selectWritable(tx.Update(article)).RequireMatch().Commit(ctx)
+		})
+		if err != nil {
+			return err
+		}
+
+		updated, err = findArticle(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		return t.createRevision(ctx, tx, updated, &previous)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// selectWritable restricts an update to the fields clients may write, including fields with zero values.
+// The version is not writable by clients, but has to be incremented with every update.
+func selectWritable(dbtx db.TX) db.TX {
+	return dbtx.Select("version", "title", "slug", "description", "content", "published", "published_at", "published_by", "publish_at", "tags", "author_id", "co_author_ids")
+}
@@ -0,0 +1,358 @@
+package article
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/log"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+)
+
+// serve handles r with a server serving a router of the articles in repo.
+// httptest sends requests from 192.0.2.1, which the server trusts to set the user header.
+func serve(repo *fakeRepository, r *http.Request, options ...Option) *httptest.ResponseRecorder {
+	logger := log.NewZerologWithWriter(io.Discard)
+	srv := server.NewDefaultServer(logger, ":0", server.WithTrustedProxies(netip.MustParsePrefix("192.0.2.1/32")))
+	srv.AddRouter(NewArticleRouter(logger, repo, options...))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	return w
+}
+
+func TestGetArticles(t *testing.T) {
+	article := &Article{
+		ID:          uuid.New(),
+		Version:     1,
+		Title:       "rendered",
+		Slug:        "rendered",
+		Content:     "# Title\n\nSome *text*.",
+		Tags:        []string{"markdown"},
+		CoAuthorIDs: []uuid.UUID{uuid.New()},
+	}
+	defaultFields := []string{
+		"author_id", "co_author_ids", "created_at", "deleted_at", "id", "published", "slug", "tags", "title", "updated_at", "version",
+	}
+	tests := []struct {
+		name  string
+		query string
+		// wantFields are the fields of the listed article.
+		wantFields []string
+		// wantHTML is the rendered content of the listed article.
+		wantHTML string
+	}{
+		{
+			name:       "default fields",
+			wantFields: defaultFields,
+		},
+		{
+			name:       "requested fields",
+			query:      "?fields=id,content",
+			wantFields: []string{"content", "id"},
+		},
+		{
+			name:       "render html",
+			query:      "?render=html",
+			wantFields: append([]string{"content", "content_html"}, defaultFields...),
+			wantHTML:   "<h1>Title</h1>\n<p>Some <em>text</em>.</p>\n",
+		},
+		{
+			name:       "render html with requested fields",
+			query:      "?render=html&fields=id,content_html",
+			wantFields: []string{"content_html", "id"},
+			wantHTML:   "<h1>Title</h1>\n<p>Some <em>text</em>.</p>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(article)
+			w := serve(repo, httptest.NewRequest(http.MethodGet, "/articles/"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %v, want %v: %s", w.Code, http.StatusOK, w.Body)
+			}
+
+			got := struct {
+				Items []map[string]any `json:"items"`
+			}{}
+			err := json.Unmarshal(w.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(got.Items) != 1 {
+				t.Fatalf("listed %d articles, want 1", len(got.Items))
+			}
+			fields := []string{}
+			for field := range got.Items[0] {
+				fields = append(fields, field)
+			}
+			if diff := cmp.Diff(tt.wantFields, fields, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("fields = %v", diff)
+			}
+			html, _ := got.Items[0]["content_html"].(string)
+			if html != tt.wantHTML {
+				t.Errorf("content_html = %q, want %q", html, tt.wantHTML)
+			}
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	author := &Author{ID: uuid.New(), Name: "author"}
+	article := &Article{ID: uuid.New(), Version: 3, Title: "title", Slug: "title", AuthorID: author.ID}
+	body := `{"title": "title", "slug": "title", "author_id": "` + author.ID.String() + `"}`
+	tests := []struct {
+		name        string
+		method      string
+		id          uuid.UUID
+		ifMatch     string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{
+			name:        "update",
+			method:      http.MethodPut,
+			id:          article.ID,
+			ifMatch:     `"3"`,
+			contentType: "application/json",
+			body:        body,
+			wantStatus:  http.StatusNoContent,
+		},
+		{
+			name:        "update modified article",
+			method:      http.MethodPut,
+			id:          article.ID,
+			ifMatch:     `"2"`,
+			contentType: "application/json",
+			body:        body,
+			wantStatus:  http.StatusPreconditionFailed,
+		},
+		{
+			name:        "update missing article",
+			method:      http.MethodPut,
+			id:          uuid.New(),
+			contentType: "application/json",
+			body:        body,
+			wantStatus:  http.StatusNotFound,
+		},
+		{
+			name:        "update missing article with precondition",
+			method:      http.MethodPut,
+			id:          uuid.New(),
+			ifMatch:     `"3"`,
+			contentType: "application/json",
+			body:        body,
+			wantStatus:  http.StatusPreconditionFailed,
+		},
+		{
+			name:        "patch missing article",
+			method:      http.MethodPatch,
+			id:          uuid.New(),
+			contentType: "application/merge-patch+json",
+			body:        `{"title": "patched"}`,
+			wantStatus:  http.StatusNotFound,
+		},
+		{
+			name:        "patch missing article with precondition",
+			method:      http.MethodPatch,
+			id:          uuid.New(),
+			ifMatch:     `"3"`,
+			contentType: "application/merge-patch+json",
+			body:        `{"title": "patched"}`,
+			wantStatus:  http.StatusPreconditionFailed,
+		},
+		{
+			name:       "delete",
+			method:     http.MethodDelete,
+			id:         article.ID,
+			ifMatch:    `"3"`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "delete modified article",
+			method:     http.MethodDelete,
+			id:         article.ID,
+			ifMatch:    `"2"`,
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "delete missing article",
+			method:     http.MethodDelete,
+			id:         uuid.New(),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "delete missing article with precondition",
+			method:     http.MethodDelete,
+			id:         uuid.New(),
+			ifMatch:    `"3"`,
+			wantStatus: http.StatusPreconditionFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(author, article)
+			r := httptest.NewRequest(tt.method, "/articles/"+tt.id.String()+"/", strings.NewReader(tt.body))
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := serve(repo, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+func TestArticleKeyset(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "default",
+			query: "",
+			want:  "created_at:desc,id:desc",
+		},
+		{
+			name:  "sort",
+			query: "sort=published_at:desc,title",
+			want:  "published_at:desc,title:asc,id:desc",
+		},
+		{
+			name:  "sort by id",
+			query: "sort=id:asc,title:desc",
+			want:  "id:asc,title:desc",
+		},
+		{
+			name:  "order_by and order",
+			query: "order_by=published_at&order=asc",
+			want:  "published_at:asc,id:asc",
+		},
+		{
+			name:  "sort overrides order_by",
+			query: "sort=title:asc&order_by=published_at",
+			want:  "title:asc,id:asc",
+		},
+		{
+			name:    "unknown order",
+			query:   "order=random",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			query:   "sort=content:asc",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate field",
+			query:   "sort=title:asc,title:desc",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery() error = %v", err)
+			}
+			got, err := articleKeyset(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("articleKeyset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("articleKeyset() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestArticleCursor(t *testing.T) {
+	records := []any{}
+	for i, title := range []string{"a", "b", "c", "d", "e"} {
+		records = append(records, &Article{ID: uuid.New(), Version: int64(i%2 + 1), Title: title, Slug: title})
+	}
+	repo := newFakeRepository(records...)
+	// page reads the titles of a page of articles and the cursors of the neighbouring pages
+	page := func(t *testing.T, query string) (titles []string, next, prev string) {
+		t.Helper()
+		w := serve(repo, httptest.NewRequest(http.MethodGet, "/articles/?limit=2&fields=title&"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %v, want %v: %s", w.Code, http.StatusOK, w.Body)
+		}
+		list := &ArticleList{}
+		err := json.Unmarshal(w.Body.Bytes(), list)
+		if err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		titles = []string{}
+		for _, article := range list.Items {
+			titles = append(titles, article.Title)
+		}
+		return titles, list.NextCursor, list.PrevCursor
+	}
+	tests := []struct {
+		name string
+		sort string
+		// want are the titles of the pages.
+		want [][]string
+	}{
+		{
+			name: "uniform order",
+			sort: "title:asc",
+			want: [][]string{{"a", "b"}, {"c", "d"}, {"e"}},
+		},
+		{
+			name: "mixed order",
+			sort: "version:desc,title:asc",
+			want: [][]string{{"b", "d"}, {"a", "c"}, {"e"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// forward through all pages
+			got := [][]string{}
+			cursors := []string{}
+			query := "sort=" + tt.sort
+			for {
+				titles, next, _ := page(t, query)
+				got = append(got, titles)
+				if next == "" {
+					break
+				}
+				cursors = append(cursors, next)
+				query = "sort=" + tt.sort + "&cursor=" + next
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("pages = %v", diff)
+			}
+
+			// backward from the last page
+			_, _, prev := page(t, "sort="+tt.sort+"&cursor="+cursors[len(cursors)-1])
+			titles, _, _ := page(t, "sort="+tt.sort+"&cursor="+prev)
+			if diff := cmp.Diff(tt.want[len(tt.want)-2], titles); diff != "" {
+				t.Errorf("previous page = %v", diff)
+			}
+
+			// a cursor cannot be used with another order
+			w := serve(repo, httptest.NewRequest(http.MethodGet, "/articles/?sort=id:asc&cursor="+cursors[0], nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %v, want %v", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
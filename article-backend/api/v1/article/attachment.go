@@ -0,0 +1,358 @@
+package article
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/blob"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/server/middleware"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+const (
+	// DefaultMaxAttachmentSize is the default maximum size of an uploaded file in bytes.
+	DefaultMaxAttachmentSize = 10 << 20
+
+	// attachmentFormField is the name of the multipart form field carrying the uploaded file.
+	attachmentFormField = "file"
+	// defaultAttachmentFilename is the filename of uploads without usable filename.
+	defaultAttachmentFilename = "attachment"
+	maxFilenameLength         = 255
+	// sniffLength is the number of bytes the content type is detected from, see http.DetectContentType.
+	sniffLength = 512
+	// multipartOverhead is the size allowed for the multipart framing of an upload in addition to the file.
+	multipartOverhead = 1 << 20
+	// uploadTimeout is the time an upload may take to be received and answered.
+	// It replaces the timeouts of the server, which are too short for large files.
+	uploadTimeout = 5 * time.Minute
+)
+
+var (
+	errAttachmentNotFound = fmt.Errorf("attachment %w", db.ErrNotFound)
+	errAttachmentTooLarge = errors.New("attachment too large")
+	// errInvalidUpload is returned if the body of an upload cannot be read, as opposed to failures of the store.
+	errInvalidUpload = errors.New("invalid upload")
+	errMissingFile   = server.ValidationErrors{{Field: attachmentFormField, Message: "must be a non-empty file"}}
+)
+
+// WithAttachments enables uploads of files to articles, which are stored in store.
+// Files larger than maxSize bytes are rejected.
+func WithAttachments(store blob.Store, maxSize int64) Option {
+	return func(t *articleRouter) {
+		if maxSize <= 0 {
+			maxSize = DefaultMaxAttachmentSize
+		}
+		t.attachments = store
+		t.maxAttachmentSize = maxSize
+	}
+}
+
+// attachmentKey returns the key the content of an attachment is stored under.
+func attachmentKey(articleID, id uuid.UUID) string {
+	return "articles/" + articleID.String() + "/" + id.String()
+}
+
+// sizeLimitReader counts and hashes the bytes read from an upload.
+// It fails with errAttachmentTooLarge once more than max bytes are read.
+type sizeLimitReader struct {
+	r    io.Reader
+	n    int64
+	max  int64
+	hash io.Writer
+}
+
+func (s *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.n += int64(n)
+	if s.n > s.max {
+		return n, fmt.Errorf("%w: the maximum size is %d bytes", errAttachmentTooLarge, s.max)
+	}
+	s.hash.Write(p[:n])
+	if err != nil && err != io.EOF {
+		return n, fmt.Errorf("%w: %w", errInvalidUpload, err)
+	}
+	return n, err
+}
+
+// getAttachments returns the attachments of an article, oldest first.
+func (t *articleRouter) getAttachments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	article, err := findArticle(ctx, t.db, chi.URLParam(r, "id"))
+	if err != nil {
+		t.writeError(w, "failed to list attachments", err)
+		return
+	}
+
+	attachments := []*Attachment{}
+	err = t.db.Find(&attachments).Where("article_id = ?", article.ID).Order("created_at", false).Order("id", false).Commit(ctx)
+	if err != nil {
+		t.writeError(w, "failed to list attachments", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, AttachmentList{
+		Items: attachments,
+	})
+}
+
+// getAttachment returns the description of an attachment.
+func (t *articleRouter) getAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	article, err := findArticle(ctx, t.db, chi.URLParam(r, "id"))
+	if err != nil {
+		t.writeError(w, "failed to get attachment", err)
+		return
+	}
+	attachment, err := findAttachment(ctx, t.db, article.ID, chi.URLParam(r, "attachment"))
+	if err != nil {
+		t.writeError(w, "failed to get attachment", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, attachment)
+}
+
+// getAttachmentContent returns the content of an attachment.
+// Range and conditional requests are supported. Images are shown inline, all other files are downloaded.
+func (t *articleRouter) getAttachmentContent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	article, err := findArticle(ctx, t.db, chi.URLParam(r, "id"))
+	if err != nil {
+		t.writeError(w, "failed to get attachment", err)
+		return
+	}
+	attachment, err := findAttachment(ctx, t.db, article.ID, chi.URLParam(r, "attachment"))
+	if err != nil {
+		t.writeError(w, "failed to get attachment", err)
+		return
+	}
+	content, err := t.attachments.Open(ctx, attachmentKey(attachment.ArticleID, attachment.ID))
+	if errors.Is(err, blob.ErrNotFound) {
+		err = fmt.Errorf("content of %w", errAttachmentNotFound)
+	}
+	if err != nil {
+		t.writeError(w, "failed to get attachment", err)
+		return
+	}
+	defer content.Close()
+
+	disposition := "attachment"
+	if strings.HasPrefix(attachment.ContentType, "image/") {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	// the content type was detected from the content, browsers must not guess another one
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// attachments are immutable, so their checksum identifies the content
+	w.Header().Set("ETag", `"`+attachment.SHA256+`"`)
+	http.ServeContent(w, r, attachment.Filename, attachment.CreatedAt, content)
+}
+
+// uploadAttachment adds the file of a multipart/form-data request to an article.
+// The file is expected in the form field "file". Its content type is detected from its content.
+func (t *articleRouter) uploadAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	article, err := findArticle(ctx, t.db, chi.URLParam(r, "id"))
+	if err != nil {
+		t.writeError(w, "failed to upload attachment", err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(uploadTimeout)
+	err = errors.Join(rc.SetReadDeadline(deadline), rc.SetWriteDeadline(deadline))
+	if err != nil {
+		// the upload is received anyway, large files may exceed the timeouts of the server though
+		t.log.Error(err).Log("failed to extend the deadlines of an upload")
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, t.maxAttachmentSize+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "failed to parse multipart body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	attachment := &Attachment{
+		ID:        uuid.New(),
+		ArticleID: article.ID,
+	}
+	if uploaderID, ok := middleware.UserIDFromContext(ctx); ok {
+		attachment.UploaderID = &uploaderID
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			err = errMissingFile
+		} else if err != nil {
+			err = fmt.Errorf("%w: %w", errInvalidUpload, err)
+		}
+		if err != nil {
+			t.writeUploadError(w, err)
+			return
+		}
+		if part.FormName() != attachmentFormField {
+			continue
+		}
+
+		err = t.storeAttachment(ctx, attachment, part)
+		if err != nil {
+			t.writeUploadError(w, err)
+			return
+		}
+		break
+	}
+
+	err = t.db.Create(ctx, attachment)
+	if err != nil {
+		t.deleteAttachmentContent(ctx, attachment)
+		t.writeError(w, "failed to upload attachment", err)
+		return
+	}
+
+	w.Header().Set("Location", path.Join(r.URL.Path, attachment.ID.String()))
+	utils.WriteJSON(w, http.StatusCreated, attachment)
+}
+
+// storeAttachment stores the content of part as content of attachment and sets its description.
+func (t *articleRouter) storeAttachment(ctx context.Context, attachment *Attachment, part *multipart.Part) error {
+	sniffed := make([]byte, sniffLength)
+	n, err := io.ReadFull(part, sniffed)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %w", errInvalidUpload, err)
+	}
+	if n == 0 {
+		return errMissingFile
+	}
+	sniffed = sniffed[:n]
+
+	hash := sha256.New()
+	content := &sizeLimitReader{
+		r:    io.MultiReader(bytes.NewReader(sniffed), part),
+		max:  t.maxAttachmentSize,
+		hash: hash,
+	}
+	err = t.attachments.Put(ctx, attachmentKey(attachment.ArticleID, attachment.ID), content)
+	if err != nil {
+		return err
+	}
+
+	attachment.Filename = attachmentFilename(part.FileName())
+	attachment.ContentType = http.DetectContentType(sniffed)
+	attachment.Size = content.n
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+// attachmentFilename returns the base name of an uploaded file, which is used in Content-Disposition headers.
+func attachmentFilename(filename string) string {
+	// some clients send the full path, with the separators of their platform
+	filename = path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	filename = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, filename))
+	if filename == "" || filename == "." || filename == "/" {
+		return defaultAttachmentFilename
+	}
+	if len(filename) > maxFilenameLength {
+		filename = strings.ToValidUTF8(filename[:maxFilenameLength], "")
+	}
+	return filename
+}
+
+// writeUploadError writes the response of an upload that failed with err.
+func (t *articleRouter) writeUploadError(w http.ResponseWriter, err error) {
+	maxBytesErr := &http.MaxBytesError{}
+	switch {
+	case errors.Is(err, errAttachmentTooLarge), errors.As(err, &maxBytesErr):
+		utils.WriteJSON(w, http.StatusRequestEntityTooLarge, server.Error{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: "failed to upload attachment",
+			Error:   fmt.Sprintf("%s: the maximum size is %d bytes", errAttachmentTooLarge, t.maxAttachmentSize),
+		})
+	case errors.Is(err, errInvalidUpload):
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "failed to parse multipart body",
+			Error:   err.Error(),
+		})
+	default:
+		t.writeError(w, "failed to upload attachment", err)
+	}
+}
+
+// deleteAttachment deletes an attachment and its content.
+func (t *articleRouter) deleteAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	article, err := findArticle(ctx, t.db, chi.URLParam(r, "id"))
+	if err != nil {
+		t.writeError(w, "failed to delete attachment", err)
+		return
+	}
+	attachment, err := findAttachment(ctx, t.db, article.ID, chi.URLParam(r, "attachment"))
+	if err != nil {
+		t.writeError(w, "failed to delete attachment", err)
+		return
+	}
+	err = t.db.Delete(&Attachment{}).Where("id = ?", attachment.ID).RequireMatch().Commit(ctx)
+	if err != nil {
+		t.writeError(w, "failed to delete attachment", err)
+		return
+	}
+	t.deleteAttachmentContent(ctx, attachment)
+
+	utils.WriteJSON(w, http.StatusNoContent, map[string]any{})
+}
+
+// deleteAttachmentContent removes the content of attachments from the store.
+// Failures are logged, the content is unreachable without the attachment anyway.
+func (t *articleRouter) deleteAttachmentContent(ctx context.Context, attachments ...*Attachment) {
+	for _, attachment := range attachments {
+		err := t.attachments.Delete(ctx, attachmentKey(attachment.ArticleID, attachment.ID))
+		if err != nil {
+			t.log.Error(err).Field("attachment", attachment.ID).Log("failed to delete attachment content")
+		}
+	}
+}
+
+// findAttachment returns the attachment with the given ID of an article.
+func findAttachment(ctx context.Context, tx db.Repository, articleID uuid.UUID, id string) (*Attachment, error) {
+	attachments := []*Attachment{}
+	err := tx.Find(&attachments).Where("id = ?", id).Where("article_id = ?", articleID).Limit(1).Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		return nil, errAttachmentNotFound
+	}
+	return attachments[0], nil
+}
@@ -0,0 +1,224 @@
+package article
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/blob"
+	"github.com/leonsteinhaeuser/example-app/internal/log"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"gorm.io/gorm"
+)
+
+// multipartBody returns a multipart/form-data body with a file in field and its content type.
+func multipartBody(t *testing.T, field, filename, content string) (io.Reader, string) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile() error = %v", err)
+	}
+	io.WriteString(part, content)
+	err = writer.Close()
+	if err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return body, writer.FormDataContentType()
+}
+
+func TestUploadAttachment(t *testing.T) {
+	article := &Article{ID: uuid.New(), Title: "attachments"}
+	tests := []struct {
+		name        string
+		articleID   uuid.UUID
+		field       string
+		content     string
+		contentType string
+		wantStatus  int
+		want        *Attachment
+	}{
+		{
+			name:       "upload",
+			articleID:  article.ID,
+			field:      attachmentFormField,
+			content:    "hello world",
+			wantStatus: http.StatusCreated,
+			want: &Attachment{
+				ArticleID:   article.ID,
+				Filename:    "notes.txt",
+				ContentType: "text/plain; charset=utf-8",
+				Size:        11,
+				SHA256:      "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+			},
+		},
+		{
+			name:       "too large",
+			articleID:  article.ID,
+			field:      attachmentFormField,
+			content:    strings.Repeat("a", 65),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "missing file",
+			articleID:  article.ID,
+			field:      "document",
+			content:    "hello world",
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "unknown article",
+			articleID:  uuid.New(),
+			field:      attachmentFormField,
+			content:    "hello world",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:        "unsupported content type",
+			articleID:   article.ID,
+			field:       attachmentFormField,
+			content:     "hello world",
+			contentType: "text/plain",
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := blob.NewFileStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewFileStore() error = %v", err)
+			}
+			repo := newFakeRepository(article)
+			logger := log.NewZerologWithWriter(io.Discard)
+			srv := server.NewDefaultServer(logger, ":0")
+			srv.AddRouter(NewArticleRouter(logger, repo, WithAttachments(store, 64)))
+
+			body, contentType := multipartBody(t, tt.field, "notes.txt", tt.content)
+			if tt.contentType != "" {
+				contentType = tt.contentType
+			}
+			r := httptest.NewRequest(http.MethodPost, "/articles/"+tt.articleID.String()+"/attachments/", body)
+			r.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("upload status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.want == nil {
+				if attachments := fakeRecords[Attachment](repo); len(attachments) > 0 {
+					t.Errorf("upload stored %d attachments, want none", len(attachments))
+				}
+				return
+			}
+
+			got := &Attachment{}
+			err = json.Unmarshal(w.Body.Bytes(), got)
+			if err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(Attachment{}, "ID", "CreatedAt")); diff != "" {
+				t.Errorf("upload = %v", diff)
+			}
+			content, err := store.Open(r.Context(), attachmentKey(got.ArticleID, got.ID))
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer content.Close()
+			data, _ := io.ReadAll(content)
+			if string(data) != tt.content {
+				t.Errorf("stored content = %q, want %q", data, tt.content)
+			}
+		})
+	}
+}
+
+func TestGetAttachment(t *testing.T) {
+	article := &Article{ID: uuid.New(), Version: 1, Title: "attachments", Slug: "attachments"}
+	trashed := &Article{
+		ID:        uuid.New(),
+		Version:   1,
+		Title:     "trashed",
+		Slug:      "trashed",
+		DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true},
+	}
+	attachments := map[uuid.UUID]*Attachment{}
+	for _, a := range []*Article{article, trashed} {
+		attachments[a.ID] = &Attachment{ID: uuid.New(), ArticleID: a.ID, Filename: "notes.txt", ContentType: "text/plain", Size: 11}
+	}
+	tests := []struct {
+		name string
+		// articleID is the ID of the article in the URL, attachment the article of the attachment.
+		articleID  uuid.UUID
+		attachment *Article
+		path       string
+		wantStatus int
+	}{
+		{
+			name:       "description",
+			articleID:  article.ID,
+			attachment: article,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "content",
+			articleID:  article.ID,
+			attachment: article,
+			path:       "/content",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "description of trashed article",
+			articleID:  trashed.ID,
+			attachment: trashed,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "content of trashed article",
+			articleID:  trashed.ID,
+			attachment: trashed,
+			path:       "/content",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "attachment of another article",
+			articleID:  article.ID,
+			attachment: trashed,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := blob.NewFileStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewFileStore() error = %v", err)
+			}
+			for _, attachment := range attachments {
+				err = store.Put(context.Background(), attachmentKey(attachment.ArticleID, attachment.ID), strings.NewReader("hello world"))
+				if err != nil {
+					t.Fatalf("Put() error = %v", err)
+				}
+			}
+			repo := newFakeRepository(article, trashed, attachments[article.ID], attachments[trashed.ID])
+
+			attachmentID := attachments[tt.attachment.ID].ID
+			r := httptest.NewRequest(http.MethodGet, "/articles/"+tt.articleID.String()+"/attachments/"+attachmentID.String()+tt.path, nil)
+			w := serve(repo, r, WithAttachments(store, 64))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
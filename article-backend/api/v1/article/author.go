@@ -0,0 +1,344 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+var (
+	errAuthorNotFound = fmt.Errorf("author %w", db.ErrNotFound)
+	errAuthorInUse    = fmt.Errorf("%w: the author is referenced by articles", db.ErrConflict)
+	errInvalidExpand  = errors.New("invalid expand")
+)
+
+const (
+	expandAuthor    = "author"
+	expandCoAuthors = "co_authors"
+)
+
+// expansion is the set of author profiles embedded in article responses.
+type expansion map[string]bool
+
+// parseExpansion returns the profiles requested with the query parameter expand,
+// a comma separated list of author and co_authors.
+func parseExpansion(query url.Values) (expansion, error) {
+	expand := expansion{}
+	for _, field := range splitFields(query.Get("expand")) {
+		switch field {
+		case expandAuthor, expandCoAuthors:
+			expand[field] = true
+		default:
+			return nil, fmt.Errorf("%w: unknown field %q, expected author or co_authors", errInvalidExpand, field)
+		}
+	}
+	return expand, nil
+}
+
+// expandArticles embeds the profiles requested by expand in articles.
+// Profiles of authors that do not exist are left out.
+func expandArticles(ctx context.Context, tx db.Repository, expand expansion, articles ...*Article) error {
+	if len(expand) == 0 || len(articles) == 0 {
+		return nil
+	}
+
+	ids := []uuid.UUID{}
+	for _, article := range articles {
+		if expand[expandAuthor] {
+			ids = append(ids, article.AuthorID)
+		}
+		if expand[expandCoAuthors] {
+			ids = append(ids, article.CoAuthorIDs...)
+		}
+	}
+	authors, err := findAuthors(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, article := range articles {
+		if expand[expandAuthor] {
+			article.Author = authors[article.AuthorID]
+		}
+		if expand[expandCoAuthors] {
+			article.CoAuthors = make([]*Author, 0, len(article.CoAuthorIDs))
+			for _, id := range article.CoAuthorIDs {
+				if author, ok := authors[id]; ok {
+					article.CoAuthors = append(article.CoAuthors, author)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// findAuthors returns the authors with the given IDs by ID. IDs of authors that do not exist are missing.
+func findAuthors(ctx context.Context, tx db.Repository, ids []uuid.UUID) (map[uuid.UUID]*Author, error) {
+	found := map[uuid.UUID]*Author{}
+	if len(ids) == 0 {
+		return found, nil
+	}
+	authors := []*Author{}
+	err := tx.Find(&authors).Where("id IN ?", ids).Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, author := range authors {
+		found[author.ID] = author
+	}
+	return found, nil
+}
+
+// authorReference is a field of an article referencing an author.
+type authorReference struct {
+	field string
+	id    uuid.UUID
+}
+
+// authorReferences returns the authors referenced by article: its author, co-authors and publisher.
+func authorReferences(article *Article) []authorReference {
+	references := []authorReference{{field: "author_id", id: article.AuthorID}}
+	for i, id := range article.CoAuthorIDs {
+		references = append(references, authorReference{field: fmt.Sprintf("co_author_ids[%d]", i), id: id})
+	}
+	if article.PublishedBy != nil {
+		references = append(references, authorReference{field: "published_by", id: *article.PublishedBy})
+	}
+	return references
+}
+
+// checkAuthors returns validation errors for the authors, co-authors and publisher of article that do not exist.
+// previous is the stored state of the article, or nil if the article is new. Authors it already references
+// are not checked, so articles written before their authors were created can still be updated.
+// The checked authors are locked until the end of tx, so they cannot be deleted while the article is written.
+// The authors referenced by previous cannot be deleted anyway, since the stored article references them.
+func checkAuthors(ctx context.Context, tx db.Repository, article *Article, previous *Article) error {
+	referenced := map[uuid.UUID]bool{}
+	if previous != nil {
+		for _, reference := range authorReferences(previous) {
+			referenced[reference.id] = true
+		}
+	}
+	references := authorReferences(article)
+	ids := []uuid.UUID{}
+	for _, reference := range references {
+		if !referenced[reference.id] {
+			ids = append(ids, reference.id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	authors := []*Author{}
+	// the key share lock conflicts with deletions only, so articles of the same authors can be written concurrently
+	err := tx.Find(&authors).Select("id").Where("id IN ?", ids).ForKeyShare().Commit(ctx)
+	if err != nil {
+		return err
+	}
+	for _, author := range authors {
+		referenced[author.ID] = true
+	}
+
+	v := server.Validation{}
+	for _, reference := range references {
+		v.Check(referenced[reference.id], reference.field, "must reference an existing author")
+	}
+	return v.Err()
+}
+
+// getAuthors returns a page of authors, ordered by name.
+// query parameters:
+// - limit: int (default: 50, max: 500)
+// - offset: int, the number of authors to skip
+func (t *articleRouter) getAuthors(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	limit := defaultListLimit
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	offset := 0
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	authors := []*Author{}
+	err := t.db.Find(&authors).Order("name", false).Order("id", false).Limit(limit).Offset(offset).Commit(ctx)
+	if err != nil {
+		t.writeError(w, "failed to list authors", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, AuthorList{
+		Items: authors,
+	})
+}
+
+// getAuthor returns the author with the given ID.
+func (t *articleRouter) getAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	author, err := findAuthor(ctx, t.db, chi.URLParam(r, "id"))
+	if err != nil {
+		t.writeError(w, "failed to get author", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, author)
+}
+
+// createAuthor creates an author. The ID may be set to the ID of the user the author belongs to.
+func (t *articleRouter) createAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	author, ok := t.readAuthor(w, r)
+	if !ok {
+		return
+	}
+
+	err := t.db.Create(ctx, author)
+	if err != nil {
+		t.writeError(w, "failed to create author", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, author)
+}
+
+// updateAuthor replaces the profile of an author with the request body.
+func (t *articleRouter) updateAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	author, ok := t.readAuthor(w, r)
+	if !ok {
+		return
+	}
+
+	var updated *Author
+	err := t.db.Transaction(ctx, func(tx db.Repository) error {
+		current, err := findAuthor(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		// the author is identified by the URL, not by the body
+		author.ID = current.ID
+		err = tx.Update(author).Select("name", "email", "bio", "avatar_url").RequireMatch().Commit(ctx)
+		if err != nil {
+			return err
+		}
+		updated, err = findAuthor(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		t.writeError(w, "failed to update author", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, updated)
+}
+
+// deleteAuthor deletes an author. Authors referenced by articles, including those in the trash, cannot be deleted.
+func (t *articleRouter) deleteAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	err := t.db.Transaction(ctx, func(tx db.Repository) error {
+		authors := []*Author{}
+		// the lock keeps articles from referencing the author while it is deleted, see checkAuthors
+		err := tx.Find(&authors).Where("id = ?", id).Limit(1).ForUpdate(false).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		if len(authors) == 0 {
+			return errAuthorNotFound
+		}
+
+		references := int64(0)
+		err = tx.Count(&Article{}, &references).Unscoped().
+			Where("(author_id = ? OR co_author_ids @> ?::jsonb OR published_by = ?)", authors[0].ID, jsonArray(authors[0].ID.String()), authors[0].ID).
+			Commit(ctx)
+		if err != nil {
+			return err
+		}
+		if references > 0 {
+			return errAuthorInUse
+		}
+		return tx.Delete(&Author{}).Where("id = ?", authors[0].ID).RequireMatch().Commit(ctx)
+	})
+	if err != nil {
+		t.writeError(w, "failed to delete author", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, map[string]any{})
+}
+
+// readAuthor reads and validates the author in the body of r.
+// It returns false if the body is invalid, in which case the error has been written to w.
+func (t *articleRouter) readAuthor(w http.ResponseWriter, r *http.Request) (*Author, bool) {
+	author := &Author{}
+	err := utils.ReadJSON(r, author)
+	if err != nil {
+		t.log.Error(err).Log("failed to parse JSON body")
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "failed to parse JSON body",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+	author.Name = strings.TrimSpace(author.Name)
+	err = author.Validate()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, server.NewValidationError("invalid author", err))
+		return nil, false
+	}
+	return author, true
+}
+
+// findAuthor returns the author with the given ID.
+func findAuthor(ctx context.Context, tx db.Repository, id string) (*Author, error) {
+	authors := []*Author{}
+	err := tx.Find(&authors).Where("id = ?", id).Limit(1).Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(authors) == 0 {
+		return nil, errAuthorNotFound
+	}
+	return authors[0], nil
+}
+
+// getAuthorArticles returns the articles written or co-written by the author with the given ID.
+// Requests for authors that do not exist fail with 404 Not Found.
+// It accepts the same query parameters as getArticles.
+func (t *articleRouter) getAuthorArticles(w http.ResponseWriter, r *http.Request) {
+	// an unknown author is reported as missing rather than as an author without articles
+	_, err := findAuthor(r.Context(), t.db, chi.URLParam(r, "id"))
+	if err != nil {
+		t.writeError(w, "failed to list articles", err)
+		return
+	}
+
+	r = r.Clone(r.Context())
+	query := r.URL.Query()
+	query.Set("contributor_id", chi.URLParam(r, "id"))
+	r.URL.RawQuery = query.Encode()
+	t.getArticles(w, r)
+}
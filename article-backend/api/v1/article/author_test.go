@@ -0,0 +1,146 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+func TestAuthorReferences(t *testing.T) {
+	author := uuid.New()
+	coAuthor := uuid.New()
+	publisher := uuid.New()
+	tests := []struct {
+		name    string
+		article *Article
+		want    []authorReference
+	}{
+		{
+			name:    "author only",
+			article: &Article{AuthorID: author},
+			want:    []authorReference{{field: "author_id", id: author}},
+		},
+		{
+			name:    "co-authors and publisher",
+			article: &Article{AuthorID: author, CoAuthorIDs: []uuid.UUID{coAuthor, author}, PublishedBy: &publisher},
+			want: []authorReference{
+				{field: "author_id", id: author},
+				{field: "co_author_ids[0]", id: coAuthor},
+				{field: "co_author_ids[1]", id: author},
+				{field: "published_by", id: publisher},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := authorReferences(tt.article)
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(authorReference{})); diff != "" {
+				t.Errorf("authorReferences() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestCheckAuthorsUnchanged(t *testing.T) {
+	author := uuid.New()
+	coAuthor := uuid.New()
+	previous := &Article{AuthorID: author, CoAuthorIDs: []uuid.UUID{coAuthor}}
+	// swapping the authors references no new author, so the authors are neither read nor checked
+	article := &Article{AuthorID: coAuthor, CoAuthorIDs: []uuid.UUID{author}, PublishedBy: &author}
+
+	err := checkAuthors(context.Background(), nil, article, previous)
+	if err != nil {
+		t.Errorf("checkAuthors() error = %v", err)
+	}
+}
+
+func TestContributorFilters(t *testing.T) {
+	author := &Author{ID: uuid.New(), Name: "author"}
+	coAuthor := &Author{ID: uuid.New(), Name: "co-author"}
+	other := &Author{ID: uuid.New(), Name: "other"}
+	repo := newFakeRepository(
+		author, coAuthor, other,
+		&Article{ID: uuid.New(), Version: 1, Title: "solo", Slug: "solo", AuthorID: author.ID},
+		&Article{ID: uuid.New(), Version: 1, Title: "joint", Slug: "joint", AuthorID: author.ID, CoAuthorIDs: []uuid.UUID{coAuthor.ID}},
+		&Article{ID: uuid.New(), Version: 1, Title: "guest", Slug: "guest", AuthorID: coAuthor.ID, CoAuthorIDs: []uuid.UUID{other.ID, author.ID}},
+		&Article{ID: uuid.New(), Version: 1, Title: "other", Slug: "other", AuthorID: other.ID},
+	)
+	tests := []struct {
+		name string
+		// path is the path and query of the request, the articles are sorted by title.
+		path       string
+		wantStatus int
+		want       []string
+	}{
+		{
+			name:       "author",
+			path:       "/articles/?author_id=" + author.ID.String(),
+			wantStatus: http.StatusOK,
+			want:       []string{"joint", "solo"},
+		},
+		{
+			name:       "co-author",
+			path:       "/articles/?co_author_id=" + author.ID.String(),
+			wantStatus: http.StatusOK,
+			want:       []string{"guest"},
+		},
+		{
+			name:       "contributor",
+			path:       "/articles/?contributor_id=" + author.ID.String(),
+			wantStatus: http.StatusOK,
+			want:       []string{"guest", "joint", "solo"},
+		},
+		{
+			name:       "contributor without articles",
+			path:       "/articles/?contributor_id=" + uuid.NewString(),
+			wantStatus: http.StatusOK,
+			want:       []string{},
+		},
+		{
+			name:       "articles of author",
+			path:       "/authors/" + coAuthor.ID.String() + "/articles?",
+			wantStatus: http.StatusOK,
+			want:       []string{"guest", "joint"},
+		},
+		{
+			name:       "articles of author with filter",
+			path:       "/authors/" + other.ID.String() + "/articles?author_id=" + other.ID.String(),
+			wantStatus: http.StatusOK,
+			want:       []string{"other"},
+		},
+		{
+			name:       "articles of unknown author",
+			path:       "/authors/" + uuid.NewString() + "/articles?",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(repo, httptest.NewRequest(http.MethodGet, tt.path+"&sort=title:asc&fields=title", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.want == nil {
+				return
+			}
+
+			list := &ArticleList{}
+			err := json.Unmarshal(w.Body.Bytes(), list)
+			if err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			got := []string{}
+			for _, article := range list.Items {
+				got = append(got, article.Title)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("articles = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/pubsub"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+// BatchOperationType is the kind of change of a batch operation.
+type BatchOperationType string
+
+const (
+	BatchOperationCreate BatchOperationType = "create"
+	BatchOperationUpdate BatchOperationType = "update"
+	BatchOperationDelete BatchOperationType = "delete"
+
+	// maxBatchSize is the maximum number of operations of a batch request.
+	maxBatchSize = 1000
+)
+
+var (
+	errInvalidBatchOperation = errors.New("invalid batch operation")
+	errPreconditionRequired  = errors.New("modifying an article requires if_match to be set to its ETag")
+	errBatchAborted          = errors.New("batch aborted")
+)
+
+// BatchRequest is the body of a batch request.
+type BatchRequest struct {
+	// Atomic runs all operations in a single transaction. If one operation fails, no change is stored.
+	// Otherwise every operation is stored on its own and failed operations do not affect the others.
+	Atomic bool `json:"atomic"`
+	// Operations are executed in order.
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation is a single change of a batch request.
+type BatchOperation struct {
+	// Op is the kind of change.
+	Op BatchOperationType `json:"op"`
+	// ID is the ID of the article to update or delete.
+	ID string `json:"id,omitempty"`
+	// IfMatch is the ETag the article to update or delete must match, like the If-Match header.
+	IfMatch string `json:"if_match,omitempty"`
+	// Article is the article to create, or the replacement of the article to update.
+	Article *Article `json:"article,omitempty"`
+}
+
+// BatchResult is the outcome of a batch operation.
+type BatchResult struct {
+	// Status is the HTTP status code the operation would have been answered with on its own.
+	Status int `json:"status"`
+	// ID is the ID of the affected article.
+	ID uuid.UUID `json:"id,omitempty"`
+	// Article is the created or updated article.
+	Article *Article `json:"article,omitempty"`
+	// Error describes why the operation failed.
+	Error *server.Error `json:"error,omitempty"`
+}
+
+// BatchResponse is the response of a batch request.
+type BatchResponse struct {
+	// Items are the results of the operations, in the order of the request.
+	Items []BatchResult `json:"items"`
+}
+
+// batchChange is a successful operation, which is announced once it is stored.
+type batchChange struct {
+	action  pubsub.ActionType
+	article *Article
+}
+
+// batchArticles creates, updates and deletes multiple articles with one request.
+// The response contains the result of every operation.
+func (t *articleRouter) batchArticles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := BatchRequest{}
+	err := utils.ReadJSON(r, &req)
+	if err != nil {
+		t.log.Error(err).Log("failed to parse JSON body")
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "failed to parse JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+	v := server.Validation{}
+	v.Check(len(req.Operations) > 0, "operations", "must not be empty")
+	v.Check(len(req.Operations) <= maxBatchSize, "operations", fmt.Sprintf("must not contain more than %d operations", maxBatchSize))
+	err = v.Err()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, server.NewValidationError("invalid batch", err))
+		return
+	}
+
+	results := make([]BatchResult, len(req.Operations))
+	changes := []batchChange{}
+	if !req.Atomic {
+		for i, op := range req.Operations {
+			article, err := t.applyBatchOperation(ctx, op)
+			results[i] = batchResult(op, article, err)
+			if err == nil {
+				changes = append(changes, batchChange{action: batchAction(op.Op), article: article})
+			}
+		}
+	} else {
+		err = t.db.Transaction(ctx, func(tx db.Repository) error {
+			// a copy of the router bound to the transaction, so that every operation is part of it
+			bound := *t
+			bound.db = tx
+			for i, op := range req.Operations {
+				article, err := bound.applyBatchOperation(ctx, op)
+				results[i] = batchResult(op, article, err)
+				if err != nil {
+					return fmt.Errorf("%w: operation %d failed: %v", errBatchAborted, i, err)
+				}
+				changes = append(changes, batchChange{action: batchAction(op.Op), article: article})
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errBatchAborted) {
+			t.writeError(w, "failed to run batch", err)
+			return
+		}
+		if err != nil {
+			// the transaction was rolled back, so the other operations did not take effect either
+			changes = nil
+			for i := range results {
+				if results[i].Error != nil {
+					continue
+				}
+				results[i] = BatchResult{
+					Status: http.StatusFailedDependency,
+					Error: &server.Error{
+						Status:  http.StatusFailedDependency,
+						Message: "operation rolled back",
+						Error:   err.Error(),
+					},
+				}
+			}
+		}
+	}
+
+	for _, change := range changes {
+		t.articleChanged(change.action, change.article)
+	}
+	utils.WriteJSON(w, http.StatusOK, BatchResponse{
+		Items: results,
+	})
+}
+
+// applyBatchOperation executes op and returns the affected article.
+func (t *articleRouter) applyBatchOperation(ctx context.Context, op BatchOperation) (*Article, error) {
+	if op.Op != BatchOperationCreate && t.requireIfMatch && op.IfMatch == "" {
+		return nil, errPreconditionRequired
+	}
+
+	switch op.Op {
+	case BatchOperationCreate, BatchOperationUpdate:
+		if op.Article == nil {
+			return nil, fmt.Errorf("%w: %s requires an article", errInvalidBatchOperation, op.Op)
+		}
+		err := op.Article.Validate()
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == BatchOperationCreate {
+			return op.Article, t.insertArticle(ctx, op.Article)
+		}
+		// an update replaces all writable fields, like PUT
+		return t.replaceArticle(ctx, op.ID, op.IfMatch, func(db.Repository, *Article) (*Article, error) {
+			return op.Article, nil
+		})
+	case BatchOperationDelete:
+		return t.removeArticle(ctx, op.ID, op.IfMatch)
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q, expected create, update or delete", errInvalidBatchOperation, op.Op)
+	}
+}
+
+// batchResult returns the result of an operation that returned article and err.
+func batchResult(op BatchOperation, article *Article, err error) BatchResult {
+	if err != nil {
+		status := batchErrorStatus(err)
+		if status == http.StatusUnprocessableEntity {
+			e := server.NewValidationError("invalid article", err)
+			return BatchResult{Status: status, Error: &e}
+		}
+		return BatchResult{
+			Status: status,
+			Error: &server.Error{
+				Status:  status,
+				Message: fmt.Sprintf("failed to %s article", op.Op),
+				Error:   err.Error(),
+			},
+		}
+	}
+
+	result := BatchResult{Status: http.StatusOK}
+	switch op.Op {
+	case BatchOperationCreate:
+		result.Status = http.StatusCreated
+	case BatchOperationDelete:
+		result.Status = http.StatusNoContent
+		result.ID = article.ID
+		return result
+	}
+	result.ID = article.ID
+	result.Article = article
+	return result
+}
+
+// batchErrorStatus returns the HTTP status code of a failed operation.
+func batchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidBatchOperation):
+		return http.StatusBadRequest
+	case errors.Is(err, errPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, errPreconditionRequired):
+		return http.StatusPreconditionRequired
+	default:
+		return server.StatusCode(err)
+	}
+}
+
+// batchAction returns the event action of an operation.
+func batchAction(op BatchOperationType) pubsub.ActionType {
+	switch op {
+	case BatchOperationCreate:
+		return pubsub.ActionTypeCreate
+	case BatchOperationDelete:
+		return pubsub.ActionTypeDelete
+	default:
+		return pubsub.ActionTypeUpdate
+	}
+}
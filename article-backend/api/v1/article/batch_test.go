@@ -0,0 +1,239 @@
+package article
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+)
+
+func TestBatchResult(t *testing.T) {
+	article := &Article{ID: uuid.New(), Version: 1, Title: "title"}
+	validation := server.ValidationErrors{{Field: "title", Message: "must not be empty"}}
+	tests := []struct {
+		name    string
+		op      BatchOperationType
+		article *Article
+		err     error
+		want    BatchResult
+	}{
+		{
+			name:    "create",
+			op:      BatchOperationCreate,
+			article: article,
+			want:    BatchResult{Status: http.StatusCreated, ID: article.ID, Article: article},
+		},
+		{
+			name:    "update",
+			op:      BatchOperationUpdate,
+			article: article,
+			want:    BatchResult{Status: http.StatusOK, ID: article.ID, Article: article},
+		},
+		{
+			name:    "delete",
+			op:      BatchOperationDelete,
+			article: article,
+			want:    BatchResult{Status: http.StatusNoContent, ID: article.ID},
+		},
+		{
+			name: "invalid article",
+			op:   BatchOperationCreate,
+			err:  validation,
+			want: BatchResult{Status: http.StatusUnprocessableEntity, Error: &server.Error{
+				Status:  http.StatusUnprocessableEntity,
+				Message: "invalid article",
+				Error:   validation.Error(),
+				Details: validation,
+			}},
+		},
+		{
+			name: "missing article",
+			op:   BatchOperationUpdate,
+			err:  errArticleNotFound,
+			want: BatchResult{Status: http.StatusNotFound, Error: &server.Error{
+				Status:  http.StatusNotFound,
+				Message: "failed to update article",
+				Error:   errArticleNotFound.Error(),
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := batchResult(BatchOperation{Op: tt.op}, tt.article, tt.err)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("batchResult() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestBatchErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "invalid operation",
+			err:  fmt.Errorf("%w: unknown operation", errInvalidBatchOperation),
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "precondition failed",
+			err:  errPreconditionFailed,
+			want: http.StatusPreconditionFailed,
+		},
+		{
+			name: "precondition required",
+			err:  errPreconditionRequired,
+			want: http.StatusPreconditionRequired,
+		},
+		{
+			name: "validation",
+			err:  server.ValidationErrors{{Field: "title", Message: "must not be empty"}},
+			want: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "not found",
+			err:  errArticleNotFound,
+			want: http.StatusNotFound,
+		},
+		{
+			name: "conflict",
+			err:  fmt.Errorf("%w: slug is taken", db.ErrConflict),
+			want: http.StatusConflict,
+		},
+		{
+			name: "other",
+			err:  errors.New("connection refused"),
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batchErrorStatus(tt.err); got != tt.want {
+				t.Errorf("batchErrorStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchArticles(t *testing.T) {
+	author := &Author{ID: uuid.New(), Name: "author"}
+	updated := &Article{ID: uuid.New(), Version: 1, Title: "updated", Slug: "updated", AuthorID: author.ID}
+	deleted := &Article{ID: uuid.New(), Version: 1, Title: "deleted", Slug: "deleted", AuthorID: author.ID}
+	operations := func(ops ...string) string {
+		return `[` + strings.Join(ops, ",") + `]`
+	}
+	create := `{"op": "create", "article": {"title": "created", "author_id": "` + author.ID.String() + `"}}`
+	update := `{"op": "update", "id": "` + updated.ID.String() + `", "article": {"title": "changed", "author_id": "` + author.ID.String() + `"}}`
+	remove := `{"op": "delete", "id": "` + deleted.ID.String() + `"}`
+	stale := `{"op": "delete", "id": "` + deleted.ID.String() + `", "if_match": "\"2\""}`
+	tests := []struct {
+		name string
+		body string
+		// options are passed to the router.
+		options    []Option
+		wantStatus int
+		// wantItems are the statuses of the operations.
+		wantItems []int
+		// wantTitles are the titles of the articles that are not deleted after the request.
+		wantTitles []string
+	}{
+		{
+			name:       "mixed operations",
+			body:       `{"operations": ` + operations(create, update, remove) + `}`,
+			wantStatus: http.StatusOK,
+			wantItems:  []int{http.StatusCreated, http.StatusOK, http.StatusNoContent},
+			wantTitles: []string{"changed", "created"},
+		},
+		{
+			name:       "failed operation",
+			body:       `{"operations": ` + operations(create, stale, update) + `}`,
+			wantStatus: http.StatusOK,
+			wantItems:  []int{http.StatusCreated, http.StatusPreconditionFailed, http.StatusOK},
+			wantTitles: []string{"changed", "created", "deleted"},
+		},
+		{
+			name:       "atomic",
+			body:       `{"atomic": true, "operations": ` + operations(create, update, remove) + `}`,
+			wantStatus: http.StatusOK,
+			wantItems:  []int{http.StatusCreated, http.StatusOK, http.StatusNoContent},
+			wantTitles: []string{"changed", "created"},
+		},
+		{
+			name:       "atomic with failed operation",
+			body:       `{"atomic": true, "operations": ` + operations(create, update, stale) + `}`,
+			wantStatus: http.StatusOK,
+			wantItems:  []int{http.StatusFailedDependency, http.StatusFailedDependency, http.StatusPreconditionFailed},
+			wantTitles: []string{"deleted", "updated"},
+		},
+		{
+			name:       "unknown operation",
+			body:       `{"operations": [{"op": "purge", "id": "` + deleted.ID.String() + `"}]}`,
+			wantStatus: http.StatusOK,
+			wantItems:  []int{http.StatusBadRequest},
+			wantTitles: []string{"deleted", "updated"},
+		},
+		{
+			name:       "if_match required",
+			body:       `{"operations": ` + operations(create, remove) + `}`,
+			options:    []Option{WithRequireIfMatch(true)},
+			wantStatus: http.StatusOK,
+			wantItems:  []int{http.StatusCreated, http.StatusPreconditionRequired},
+			wantTitles: []string{"created", "deleted", "updated"},
+		},
+		{
+			name:       "no operations",
+			body:       `{"operations": []}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantTitles: []string{"deleted", "updated"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(author, updated, deleted)
+			repo.query = noRows
+			r := httptest.NewRequest(http.MethodPost, "/articles:batch", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := serve(repo, r, tt.options...)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantItems != nil {
+				got := &BatchResponse{}
+				err := json.Unmarshal(w.Body.Bytes(), got)
+				if err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				items := []int{}
+				for _, item := range got.Items {
+					items = append(items, item.Status)
+				}
+				if diff := cmp.Diff(tt.wantItems, items); diff != "" {
+					t.Errorf("items = %v", diff)
+				}
+			}
+
+			titles := []string{}
+			for _, article := range fakeRecords[Article](repo) {
+				if !article.DeletedAt.Valid {
+					titles = append(titles, article.Title)
+				}
+			}
+			if diff := cmp.Diff(tt.wantTitles, titles, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("articles = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package article
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/keystore"
+)
+
+// DefaultCacheTTL is the default time articles and list responses are cached for.
+const DefaultCacheTTL = time.Minute
+
+const (
+	// cacheArticleKeyPrefix is the prefix of the keys of cached articles,
+	// followed by the ID of the article and the generation of the article.
+	cacheArticleKeyPrefix = "article:"
+	// cacheArticleGenerationSuffix follows the ID of an article in the key holding its current generation.
+	// Changing it invalidates the cached article.
+	cacheArticleGenerationSuffix = ":generation"
+	// cacheListKeyPrefix is the prefix of the keys of cached list responses,
+	// followed by the list generation and the hash of the list query.
+	cacheListKeyPrefix = "articles:list:"
+	// cacheListGenerationKey holds the current list generation. Changing it invalidates all cached lists,
+	// which then expire unused.
+	cacheListGenerationKey = "articles:generation"
+)
+
+// WithCache caches articles and list responses in store for ttl.
+// Cached entries are invalidated when an article is changed. Since the store is shared,
+// this includes changes made by other replicas.
+func WithCache(store keystore.KeyStore, ttl time.Duration) Option {
+	return func(t *articleRouter) {
+		if ttl <= 0 {
+			ttl = DefaultCacheTTL
+		}
+		t.cache = store
+		t.cacheTTL = ttl
+	}
+}
+
+// articlePage is a page of articles as cached for list requests.
+type articlePage struct {
+	List ArticleList `json:"list"`
+	// Total is the total number of matching articles, if it was requested.
+	Total *int64 `json:"total,omitempty"`
+}
+
+// findCachedArticle returns the article with the given ID. The article is read from the cache if possible.
+// The ID is parsed first, so that all spellings of an ID share the cache entry invalidated by invalidateCache.
+func (t *articleRouter) findCachedArticle(ctx context.Context, id string) (*Article, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid article ID: %w", db.ErrInvalidInput, err)
+	}
+	return t.cachedArticle(ctx, parsed, func(ctx context.Context) (*Article, error) {
+		return findArticle(ctx, t.db, parsed.String())
+	})
+}
+
+// cachedArticle returns the cached article with the given ID or, on a cache miss, the article returned by load,
+// which is then cached. The article is cached under the generation of the article read before load.
+// An article changed while it is loaded gets a new generation, so the outdated article is cached
+// under a key that is not read anymore.
+func (t *articleRouter) cachedArticle(ctx context.Context, id uuid.UUID, load func(context.Context) (*Article, error)) (*Article, error) {
+	key := ""
+	if generation, ok := t.cacheGeneration(ctx, cacheArticleKeyPrefix+id.String()+cacheArticleGenerationSuffix, t.cacheTTL); ok {
+		key = cacheArticleKeyPrefix + id.String() + ":" + generation
+	}
+	article := &Article{}
+	if t.cacheGet(ctx, key, article) {
+		return article, nil
+	}
+	article, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.cacheSet(ctx, key, article)
+	return article, nil
+}
+
+// cacheGeneration returns the generation stored under key. A new generation is stored for ttl if there is none.
+// It returns false if caching is disabled or the generation cannot be determined.
+func (t *articleRouter) cacheGeneration(ctx context.Context, key string, ttl time.Duration) (string, bool) {
+	if t.cache == nil {
+		return "", false
+	}
+	generation, err := t.cache.Get(ctx, key)
+	if errors.Is(err, keystore.ErrKeyNotFound) {
+		generation = []byte(uuid.NewString())
+		err = t.cache.Set(ctx, key, generation, ttl)
+	}
+	if err != nil {
+		t.log.Error(err).Field("key", key).Log("failed to get cache generation")
+		return "", false
+	}
+	return string(generation), true
+}
+
+// listCacheKey returns the key of the cached response to a list request with query.
+// It returns an empty key if caching is disabled or the current list generation cannot be determined.
+func (t *articleRouter) listCacheKey(ctx context.Context, query url.Values) string {
+	generation, ok := t.cacheGeneration(ctx, cacheListGenerationKey, 0)
+	if !ok {
+		return ""
+	}
+
+	// render is part of the key, since rendering includes the content in the columns read by default.
+	// Encode sorts the parameters, so equal queries have equal keys
+	hash := sha256.Sum256([]byte(query.Encode()))
+	return cacheListKeyPrefix + generation + ":" + hex.EncodeToString(hash[:])
+}
+
+// invalidateCache invalidates the cached state of the article with the given ID and all cached lists,
+// since the article may have entered, left or moved within any of them.
+// Replacing the generation instead of deleting the cached article keeps reads that started before the change
+// from caching the previous state again.
+func (t *articleRouter) invalidateCache(ctx context.Context, id uuid.UUID) {
+	if t.cache == nil {
+		return
+	}
+	err := t.cache.Set(ctx, cacheArticleKeyPrefix+id.String()+cacheArticleGenerationSuffix, uuid.NewString(), t.cacheTTL)
+	if err != nil {
+		t.log.Error(err).Field("article", id).Log("failed to invalidate cached article")
+	}
+	err = t.cache.Set(ctx, cacheListGenerationKey, uuid.NewString(), 0)
+	if err != nil {
+		t.log.Error(err).Log("failed to invalidate cached lists")
+	}
+}
+
+// cacheGet reads the cached value of key into v and returns true on a cache hit.
+// Failures of the cache are logged and treated as a miss.
+func (t *articleRouter) cacheGet(ctx context.Context, key string, v any) bool {
+	if t.cache == nil || key == "" {
+		return false
+	}
+	data, err := t.cache.Get(ctx, key)
+	if errors.Is(err, keystore.ErrKeyNotFound) {
+		t.log.Debug().Field("key", key).Log("cache miss")
+		return false
+	}
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		t.log.Error(err).Field("key", key).Log("failed to read from cache")
+		return false
+	}
+	t.log.Debug().Field("key", key).Log("cache hit")
+	return true
+}
+
+// cacheSet caches v under key. Failures of the cache are logged.
+func (t *articleRouter) cacheSet(ctx context.Context, key string, v any) {
+	if t.cache == nil || key == "" {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = t.cache.Set(ctx, key, data, t.cacheTTL)
+	}
+	if err != nil {
+		t.log.Error(err).Field("key", key).Log("failed to write to cache")
+	}
+}
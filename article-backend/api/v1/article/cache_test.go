@@ -0,0 +1,174 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/keystore"
+	"github.com/leonsteinhaeuser/example-app/internal/log"
+)
+
+// memoryKeyStore is a keystore.KeyStore in memory. Entries do not expire.
+type memoryKeyStore struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func (m *memoryKeyStore) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.values[key]
+	if !ok {
+		return nil, keystore.ErrKeyNotFound
+	}
+	return value, nil
+}
+
+func (m *memoryKeyStore) Set(_ context.Context, key string, value any, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch v := value.(type) {
+	case []byte:
+		m.values[key] = v
+	case string:
+		m.values[key] = []byte(v)
+	default:
+		return fmt.Errorf("unsupported value type %T", value)
+	}
+	return nil
+}
+
+func (m *memoryKeyStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func TestCachedArticle(t *testing.T) {
+	id := uuid.New()
+	before := &Article{ID: id, Title: "before", Version: 1}
+	after := &Article{ID: id, Title: "after", Version: 2}
+
+	tests := []struct {
+		name string
+		// loads are the results of the loads of both reads, in order.
+		loads []*Article
+		// changed is called during the first load.
+		changed func(t *articleRouter)
+		want    []*Article
+		// wantLoads is the number of reads missing the cache.
+		wantLoads int
+	}{
+		{
+			name:      "cache hit",
+			loads:     []*Article{before},
+			want:      []*Article{before, before},
+			wantLoads: 1,
+		},
+		{
+			name:  "changed before the cache is set",
+			loads: []*Article{before, after},
+			changed: func(t *articleRouter) {
+				t.invalidateCache(context.Background(), id)
+			},
+			// the first read returns the state it loaded, but must not cache it
+			want:      []*Article{before, after},
+			wantLoads: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := NewArticleRouter(log.NewZerologWithWriter(io.Discard), nil, WithCache(&memoryKeyStore{values: map[string][]byte{}}, time.Minute))
+
+			loads := 0
+			load := func(context.Context) (*Article, error) {
+				article := *tt.loads[loads]
+				loads++
+				if loads == 1 && tt.changed != nil {
+					tt.changed(rt)
+				}
+				return &article, nil
+			}
+
+			got := []*Article{}
+			for range tt.want {
+				article, err := rt.cachedArticle(context.Background(), id, load)
+				if err != nil {
+					t.Fatalf("cachedArticle() error = %v", err)
+				}
+				got = append(got, article)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("cachedArticle() = %v", diff)
+			}
+			if loads != tt.wantLoads {
+				t.Errorf("cachedArticle() loaded %d times, want %d", loads, tt.wantLoads)
+			}
+		})
+	}
+}
+
+func TestFindCachedArticle(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		name string
+		// ids are the spellings of the ID the article is read with, before and after it is changed.
+		ids       []string
+		wantTitle []string
+		wantErr   error
+	}{
+		{
+			name:      "canonical ID",
+			ids:       []string{id.String(), id.String()},
+			wantTitle: []string{"before", "after"},
+		},
+		{
+			name:      "non-canonical ID",
+			ids:       []string{strings.ToUpper(id.String()), strings.ToUpper(id.String())},
+			wantTitle: []string{"before", "after"},
+		},
+		{
+			name:    "invalid ID",
+			ids:     []string{"article"},
+			wantErr: db.ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(&Article{ID: id, Title: "before", Version: 1})
+			rt := NewArticleRouter(log.NewZerologWithWriter(io.Discard), repo, WithCache(&memoryKeyStore{values: map[string][]byte{}}, time.Minute))
+
+			got := []string{}
+			for i, readID := range tt.ids {
+				if i > 0 {
+					err := repo.Update(&Article{ID: id, Title: "after", Version: 2}).Commit(context.Background())
+					if err != nil {
+						t.Fatalf("Update() error = %v", err)
+					}
+					rt.invalidateCache(context.Background(), id)
+				}
+				article, err := rt.findCachedArticle(context.Background(), readID)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("findCachedArticle() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if err != nil {
+					return
+				}
+				got = append(got, article.Title)
+			}
+			if diff := cmp.Diff(tt.wantTitle, got); diff != "" {
+				t.Errorf("findCachedArticle() = %v", diff)
+			}
+		})
+	}
+}
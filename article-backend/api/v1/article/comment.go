@@ -0,0 +1,382 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/server/middleware"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+var (
+	errCommentNotFound     = fmt.Errorf("comment %w", db.ErrNotFound)
+	errCommentUserRequired = fmt.Errorf("%w: comments can only be written, changed and moderated by users", server.ErrUnauthenticated)
+	errNotCommentAuthor    = fmt.Errorf("%w: only the author may change a comment", server.ErrForbidden)
+	errNotModerator        = fmt.Errorf("%w: only the authors of the article may moderate its comments", server.ErrForbidden)
+	errInvalidParent       = server.ValidationErrors{{Field: "parent_id", Message: "must be an approved comment of the article"}}
+)
+
+// getComments returns the comments of an article, oldest first.
+// query parameters:
+//   - status: pending, approved (default) or rejected. Approved comments are returned as threads,
+//     with replies nested in their parent. The other states are returned as flat list
+//     and only to the authors of the article.
+func (t *articleRouter) getComments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	status := CommentStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = CommentStatusApproved
+	}
+	if !status.Valid() {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid status",
+			Error:   fmt.Sprintf("unknown status %q, expected pending, approved or rejected", status),
+		})
+		return
+	}
+
+	article, err := findCommentedArticle(ctx, t.db, id)
+	if err != nil {
+		t.writeError(w, "failed to list comments", err)
+		return
+	}
+	if status != CommentStatusApproved {
+		// comments awaiting or failing moderation are only visible to the moderators
+		err = authorizeModerator(ctx, article)
+		if err != nil {
+			t.writeError(w, "failed to list comments", err)
+			return
+		}
+	}
+
+	comments := []*Comment{}
+	err = t.db.Find(&comments).
+		Where("article_id = ?", article.ID).
+		Where("status = ?", status).
+		Order("created_at", false).
+		Order("id", false).
+		Commit(ctx)
+	if err != nil {
+		t.writeError(w, "failed to list comments", err)
+		return
+	}
+	if status == CommentStatusApproved {
+		comments = commentThread(comments)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, CommentList{
+		Items: comments,
+	})
+}
+
+// commentThread nests the replies of comments in their parents and returns the top-level comments.
+// comments must be ordered oldest first. Replies to comments that are not part of comments,
+// e.g. because they are deleted or not approved, are left out together with their own replies.
+func commentThread(comments []*Comment) []*Comment {
+	byID := make(map[uuid.UUID]*Comment, len(comments))
+	for _, comment := range comments {
+		byID[comment.ID] = comment
+	}
+
+	thread := []*Comment{}
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			thread = append(thread, comment)
+			continue
+		}
+		if parent, ok := byID[*comment.ParentID]; ok {
+			parent.Replies = append(parent.Replies, comment)
+		}
+	}
+	return thread
+}
+
+// getComment returns a comment of an article.
+// Comments that are not approved are only visible to their author and the moderators.
+func (t *articleRouter) getComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	article, err := findCommentedArticle(ctx, t.db, chi.URLParam(r, "id"))
+	if err != nil {
+		t.writeError(w, "failed to get comment", err)
+		return
+	}
+	comment, err := findComment(ctx, t.db, article.ID, chi.URLParam(r, "comment"))
+	if err != nil {
+		t.writeError(w, "failed to get comment", err)
+		return
+	}
+	if comment.Status != CommentStatusApproved && authorizeCommentAuthor(ctx, comment) != nil && authorizeModerator(ctx, article) != nil {
+		// hidden comments are reported as missing, so their existence is not revealed
+		t.writeError(w, "failed to get comment", errCommentNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, comment)
+}
+
+// createComment adds a comment to a published article, or a reply to an approved comment.
+// New comments are pending until they are approved by a moderator.
+func (t *articleRouter) createComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		t.writeError(w, "failed to create comment", errCommentUserRequired)
+		return
+	}
+
+	req, ok := t.readCommentRequest(w, r)
+	if !ok {
+		return
+	}
+
+	article, err := findCommentedArticle(ctx, t.db, id)
+	if err != nil {
+		t.writeError(w, "failed to create comment", err)
+		return
+	}
+	if !article.Published {
+		// the moderators see unpublished articles, but readers cannot reply to comments on them
+		t.writeError(w, "failed to create comment", errArticleNotFound)
+		return
+	}
+
+	if req.ParentID != nil {
+		parent, err := findComment(ctx, t.db, article.ID, req.ParentID.String())
+		if errors.Is(err, db.ErrNotFound) || (err == nil && parent.Status != CommentStatusApproved) {
+			err = errInvalidParent
+		}
+		if err != nil {
+			t.writeError(w, "failed to create comment", err)
+			return
+		}
+	}
+
+	comment := &Comment{
+		ArticleID: article.ID,
+		ParentID:  req.ParentID,
+		AuthorID:  userID,
+		Content:   req.Content,
+		Status:    CommentStatusPending,
+	}
+	err = t.db.Create(ctx, comment)
+	if err != nil {
+		t.writeError(w, "failed to create comment", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, comment)
+}
+
+// updateComment replaces the content of a comment. Only the author may edit a comment.
+// Edited comments have to be approved again.
+func (t *articleRouter) updateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, ok := t.readCommentRequest(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := t.changeComment(ctx, chi.URLParam(r, "id"), chi.URLParam(r, "comment"), authorizeCommentAuthor, func(tx db.Repository, comment *Comment) error {
+		comment.Content = req.Content
+		comment.Status = CommentStatusPending
+		comment.ModeratedBy = nil
+		comment.ModeratedAt = nil
+		return tx.Update(comment).Select("content", "status", "moderated_by", "moderated_at").RequireMatch().Commit(ctx)
+	})
+	if err != nil {
+		t.writeError(w, "failed to update comment", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, comment)
+}
+
+// deleteComment deletes a comment. Only the author may delete a comment.
+// The replies to a deleted comment are hidden with it.
+func (t *articleRouter) deleteComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	_, err := t.changeComment(ctx, chi.URLParam(r, "id"), chi.URLParam(r, "comment"), authorizeCommentAuthor, func(tx db.Repository, comment *Comment) error {
+		return tx.Delete(&Comment{}).Where("id = ?", comment.ID).RequireMatch().Commit(ctx)
+	})
+	if err != nil {
+		t.writeError(w, "failed to delete comment", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, map[string]any{})
+}
+
+// moderateComment sets the moderation state of a comment. Only the authors of the article may moderate its comments.
+func (t *articleRouter) moderateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	req := ModerationRequest{}
+	err := utils.ReadJSON(r, &req)
+	if err != nil {
+		t.log.Error(err).Log("failed to parse JSON body")
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "failed to parse JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+	err = req.Validate()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, server.NewValidationError("invalid moderation", err))
+		return
+	}
+
+	article, err := findCommentedArticle(ctx, t.db, id)
+	if err != nil {
+		t.writeError(w, "failed to moderate comment", err)
+		return
+	}
+	authorize := func(ctx context.Context, _ *Comment) error {
+		return authorizeModerator(ctx, article)
+	}
+
+	comment, err := t.changeComment(ctx, id, chi.URLParam(r, "comment"), authorize, func(tx db.Repository, comment *Comment) error {
+		moderatorID, _ := middleware.UserIDFromContext(ctx)
+		now := time.Now()
+		comment.Status = req.Status
+		comment.ModeratedBy = &moderatorID
+		comment.ModeratedAt = &now
+		return tx.Update(comment).Select("status", "moderated_by", "moderated_at").RequireMatch().Commit(ctx)
+	})
+	if err != nil {
+		t.writeError(w, "failed to moderate comment", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, comment)
+}
+
+// readCommentRequest reads and validates the body of a request creating or editing a comment.
+// It returns false if the body is invalid, in which case the error has been written to w.
+func (t *articleRouter) readCommentRequest(w http.ResponseWriter, r *http.Request) (*CommentRequest, bool) {
+	req := &CommentRequest{}
+	err := utils.ReadJSON(r, req)
+	if err != nil {
+		t.log.Error(err).Log("failed to parse JSON body")
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "failed to parse JSON body",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+	err = req.Validate()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, server.NewValidationError("invalid comment", err))
+		return nil, false
+	}
+	return req, true
+}
+
+// changeComment locks a comment of an article, checks that the user of ctx may change it with authorize
+// and applies change within the same transaction. The article must be visible to the user, see findCommentedArticle.
+func (t *articleRouter) changeComment(ctx context.Context, articleID string, id string, authorize func(context.Context, *Comment) error, change func(tx db.Repository, comment *Comment) error) (*Comment, error) {
+	if _, ok := middleware.UserIDFromContext(ctx); !ok {
+		return nil, errCommentUserRequired
+	}
+
+	var changed *Comment
+	err := t.db.Transaction(ctx, func(tx db.Repository) error {
+		article, err := findCommentedArticle(ctx, tx, articleID)
+		if err != nil {
+			return err
+		}
+		comments := []*Comment{}
+		err = tx.Find(&comments).Where("id = ?", id).Where("article_id = ?", article.ID).Limit(1).ForUpdate(false).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		if len(comments) == 0 {
+			return errCommentNotFound
+		}
+		changed = comments[0]
+
+		err = authorize(ctx, changed)
+		if err != nil {
+			return err
+		}
+		return change(tx, changed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// findCommentedArticle returns the article with the given ID for a request on its comments.
+// Readers only see the comments of published articles, so unpublished articles are reported as missing
+// to everyone but the moderators.
+func findCommentedArticle(ctx context.Context, tx db.Repository, id string) (*Article, error) {
+	article, err := findArticle(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !article.Published && authorizeModerator(ctx, article) != nil {
+		return nil, errArticleNotFound
+	}
+	return article, nil
+}
+
+// findComment returns the comment with the given ID of an article.
+func findComment(ctx context.Context, tx db.Repository, articleID uuid.UUID, id string) (*Comment, error) {
+	comments := []*Comment{}
+	err := tx.Find(&comments).Where("id = ?", id).Where("article_id = ?", articleID).Limit(1).Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, errCommentNotFound
+	}
+	return comments[0], nil
+}
+
+// authorizeCommentAuthor returns an error unless the user of ctx wrote comment.
+func authorizeCommentAuthor(ctx context.Context, comment *Comment) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return errCommentUserRequired
+	}
+	if userID != comment.AuthorID {
+		return errNotCommentAuthor
+	}
+	return nil
+}
+
+// authorizeModerator returns an error unless the user of ctx is the author or a co-author of article.
+func authorizeModerator(ctx context.Context, article *Article) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return errCommentUserRequired
+	}
+	if userID == article.AuthorID {
+		return nil
+	}
+	for _, coAuthorID := range article.CoAuthorIDs {
+		if userID == coAuthorID {
+			return nil
+		}
+	}
+	return errNotModerator
+}
@@ -0,0 +1,241 @@
+package article
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/server/middleware"
+	"gorm.io/gorm"
+)
+
+func TestCommentArticle(t *testing.T) {
+	authorID := uuid.New()
+	readerID := uuid.New()
+	published := &Article{ID: uuid.New(), Version: 1, Title: "published", Slug: "published", AuthorID: authorID, Published: true}
+	unpublished := &Article{ID: uuid.New(), Version: 1, Title: "unpublished", Slug: "unpublished", AuthorID: authorID}
+	trashed := &Article{
+		ID:        uuid.New(),
+		Version:   1,
+		Title:     "trashed",
+		Slug:      "trashed",
+		AuthorID:  authorID,
+		Published: true,
+		DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true},
+	}
+	comments := map[uuid.UUID]*Comment{}
+	records := []any{published, unpublished, trashed}
+	for _, article := range []*Article{published, unpublished, trashed} {
+		comments[article.ID] = &Comment{ID: uuid.New(), ArticleID: article.ID, AuthorID: readerID, Content: "comment", Status: CommentStatusApproved}
+		records = append(records, comments[article.ID])
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		article *Article
+		// path is appended to the URL of the comments of the article. {comment} is replaced by the ID of its comment.
+		path       string
+		userID     uuid.UUID
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "list comments",
+			method:     http.MethodGet,
+			article:    published,
+			path:       "/",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "list comments of unpublished article",
+			method:     http.MethodGet,
+			article:    unpublished,
+			path:       "/",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "list comments of unpublished article as moderator",
+			method:     http.MethodGet,
+			article:    unpublished,
+			path:       "/",
+			userID:     authorID,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "list comments of trashed article",
+			method:     http.MethodGet,
+			article:    trashed,
+			path:       "/",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "get comment of unpublished article",
+			method:     http.MethodGet,
+			article:    unpublished,
+			path:       "/{comment}",
+			userID:     readerID,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "update comment",
+			method:     http.MethodPut,
+			article:    published,
+			path:       "/{comment}",
+			userID:     readerID,
+			body:       `{"content": "edited"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "update comment of unpublished article",
+			method:     http.MethodPut,
+			article:    unpublished,
+			path:       "/{comment}",
+			userID:     readerID,
+			body:       `{"content": "edited"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "update comment of trashed article",
+			method:     http.MethodPut,
+			article:    trashed,
+			path:       "/{comment}",
+			userID:     readerID,
+			body:       `{"content": "edited"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "delete comment of trashed article",
+			method:     http.MethodDelete,
+			article:    trashed,
+			path:       "/{comment}",
+			userID:     readerID,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "moderate comment of unpublished article",
+			method:     http.MethodPost,
+			article:    unpublished,
+			path:       "/{comment}/moderate",
+			userID:     authorID,
+			body:       `{"status": "rejected"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "moderate comment of trashed article",
+			method:     http.MethodPost,
+			article:    trashed,
+			path:       "/{comment}/moderate",
+			userID:     authorID,
+			body:       `{"status": "rejected"}`,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(records...)
+			path := strings.ReplaceAll(tt.path, "{comment}", comments[tt.article.ID].ID.String())
+			r := httptest.NewRequest(tt.method, "/articles/"+tt.article.ID.String()+"/comments"+path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			if tt.userID != uuid.Nil {
+				r.Header.Set(middleware.HeaderUserID, tt.userID.String())
+			}
+			w := serve(repo, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+// threadNode is the content of a comment and its replies, so that threads can be compared.
+type threadNode struct {
+	Content string
+	Replies []threadNode
+}
+
+func threadNodes(comments []*Comment) []threadNode {
+	nodes := []threadNode{}
+	for _, comment := range comments {
+		nodes = append(nodes, threadNode{Content: comment.Content, Replies: threadNodes(comment.Replies)})
+	}
+	return nodes
+}
+
+func TestCommentThread(t *testing.T) {
+	ids := map[string]uuid.UUID{}
+	comment := func(content, parent string) *Comment {
+		ids[content] = uuid.New()
+		c := &Comment{ID: ids[content], Content: content}
+		if parent != "" {
+			id, ok := ids[parent]
+			if !ok {
+				// the parent is not part of the comments
+				id = uuid.New()
+			}
+			c.ParentID = &id
+		}
+		return c
+	}
+	tests := []struct {
+		name     string
+		comments func() []*Comment
+		want     []threadNode
+	}{
+		{
+			name:     "no comments",
+			comments: func() []*Comment { return nil },
+			want:     []threadNode{},
+		},
+		{
+			name: "top-level comments",
+			comments: func() []*Comment {
+				return []*Comment{comment("first", ""), comment("second", "")}
+			},
+			want: []threadNode{{Content: "first", Replies: []threadNode{}}, {Content: "second", Replies: []threadNode{}}},
+		},
+		{
+			name: "nested replies",
+			comments: func() []*Comment {
+				return []*Comment{
+					comment("first", ""),
+					comment("reply", "first"),
+					comment("second", ""),
+					comment("reply to reply", "reply"),
+					comment("second reply", "first"),
+				}
+			},
+			want: []threadNode{
+				{Content: "first", Replies: []threadNode{
+					{Content: "reply", Replies: []threadNode{{Content: "reply to reply", Replies: []threadNode{}}}},
+					{Content: "second reply", Replies: []threadNode{}},
+				}},
+				{Content: "second", Replies: []threadNode{}},
+			},
+		},
+		{
+			name: "replies to hidden comments",
+			comments: func() []*Comment {
+				return []*Comment{
+					comment("first", ""),
+					comment("orphan", "hidden"),
+					comment("reply to orphan", "orphan"),
+				}
+			},
+			want: []threadNode{{Content: "first", Replies: []threadNode{}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := threadNodes(commentThread(tt.comments()))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("commentThread() = %v", diff)
+			}
+		})
+	}
+}
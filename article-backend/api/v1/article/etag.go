@@ -0,0 +1,41 @@
+package article
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+var (
+	errPreconditionFailed = errors.New("the article has been modified, If-Match does not match its ETag")
+)
+
+// articleETag returns the entity tag of the current version of an article.
+func articleETag(article *Article) string {
+	return `"` + strconv.FormatInt(article.Version, 10) + `"`
+}
+
+// ifMatchMissing writes a 428 Precondition Required response and returns true
+// if the router requires If-Match headers and r does not have one.
+func (t *articleRouter) ifMatchMissing(w http.ResponseWriter, r *http.Request) bool {
+	if !t.requireIfMatch || r.Header.Get("If-Match") != "" {
+		return false
+	}
+	utils.WriteJSON(w, http.StatusPreconditionRequired, server.Error{
+		Status:  http.StatusPreconditionRequired,
+		Message: "missing If-Match header",
+		Error:   "modifying an article requires the If-Match header to be set to its ETag",
+	})
+	return true
+}
+
+// checkIfMatch returns errPreconditionFailed if an If-Match header value is set and does not match article.
+func checkIfMatch(ifMatch string, article *Article) error {
+	if ifMatch != "" && !server.ETagMatches(ifMatch, articleETag(article), false) {
+		return errPreconditionFailed
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package article
+
+import (
+	"context"
+
+	"github.com/leonsteinhaeuser/example-app/internal/pubsub"
+)
+
+// DefaultEventTopic is the default topic article events are published on.
+const DefaultEventTopic = "articles"
+
+// WithPublisher publishes an event after each successful change of an article.
+// The topic is determined by the publisher.
+func WithPublisher(publisher pubsub.Publisher) Option {
+	return func(t *articleRouter) {
+		t.publisher = publisher
+	}
+}
+
+// articleChanged invalidates the cached state of article and notifies downstream services about its change.
+// The change is already stored, so failures are logged instead of failing the request.
+func (t *articleRouter) articleChanged(action pubsub.ActionType, article *Article) {
+	// the change must be visible even if the request that made it has been canceled meanwhile
+	t.invalidateCache(context.Background(), article.ID)
+	if t.publisher == nil {
+		return
+	}
+	err := t.publisher.Publish(&pubsub.DefaultEvent{
+		ResourceID: article.ID,
+		ActionType: action,
+		AdditionalData: map[string]any{
+			"version": article.Version,
+		},
+	})
+	if err != nil {
+		t.log.Error(err).Field("article", article.ID).Field("action", action).Log("failed to publish article event")
+	}
+}
@@ -0,0 +1,128 @@
+package article
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/pubsub"
+)
+
+// fakePublisher records the events it publishes.
+type fakePublisher struct {
+	events []*pubsub.DefaultEvent
+}
+
+func (f *fakePublisher) Publish(message pubsub.Event) error {
+	f.events = append(f.events, message.(*pubsub.DefaultEvent))
+	return nil
+}
+
+func TestArticleEvents(t *testing.T) {
+	author := &Author{ID: uuid.New(), Name: "author"}
+	article := &Article{ID: uuid.New(), Version: 1, Title: "article", Slug: "article", AuthorID: author.ID}
+	online := &Article{ID: uuid.New(), Version: 1, Title: "online", Slug: "online", AuthorID: author.ID, Published: true}
+	tests := []struct {
+		name   string
+		method string
+		// path is appended to /articles/.
+		path       string
+		body       string
+		wantStatus int
+		// wantID is the ID of the article of the events. It is not checked if it is uuid.Nil.
+		wantID      uuid.UUID
+		wantActions []pubsub.ActionType
+		// wantVersion is the version of the article sent with the event.
+		wantVersion int64
+	}{
+		{
+			name:        "create",
+			method:      http.MethodPost,
+			body:        `{"title": "new", "author_id": "` + author.ID.String() + `"}`,
+			wantStatus:  http.StatusCreated,
+			wantActions: []pubsub.ActionType{pubsub.ActionTypeCreate},
+			wantVersion: 1,
+		},
+		{
+			name:        "update",
+			method:      http.MethodPut,
+			path:        article.ID.String(),
+			body:        `{"title": "updated", "author_id": "` + author.ID.String() + `"}`,
+			wantStatus:  http.StatusNoContent,
+			wantID:      article.ID,
+			wantActions: []pubsub.ActionType{pubsub.ActionTypeUpdate},
+			wantVersion: 2,
+		},
+		{
+			name:        "delete",
+			method:      http.MethodDelete,
+			path:        article.ID.String(),
+			wantStatus:  http.StatusNoContent,
+			wantID:      article.ID,
+			wantActions: []pubsub.ActionType{pubsub.ActionTypeDelete},
+			wantVersion: 1,
+		},
+		{
+			name:        "publish",
+			method:      http.MethodPost,
+			path:        article.ID.String() + "/publish",
+			wantStatus:  http.StatusOK,
+			wantID:      article.ID,
+			wantActions: []pubsub.ActionType{pubsub.ActionTypePublish},
+			wantVersion: 2,
+		},
+		{
+			name:        "unpublish",
+			method:      http.MethodPost,
+			path:        online.ID.String() + "/unpublish",
+			wantStatus:  http.StatusOK,
+			wantID:      online.ID,
+			wantActions: []pubsub.ActionType{pubsub.ActionTypeUnpublish},
+			wantVersion: 2,
+		},
+		{
+			name:       "unchanged article",
+			method:     http.MethodPost,
+			path:       online.ID.String() + "/publish",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "failed change",
+			method:     http.MethodPut,
+			path:       uuid.New().String(),
+			body:       `{"title": "missing", "author_id": "` + author.ID.String() + `"}`,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(author, article, online)
+			repo.query = noRows
+			publisher := &fakePublisher{}
+			r := httptest.NewRequest(tt.method, "/articles/"+tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := serve(repo, r, WithPublisher(publisher))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			actions := []pubsub.ActionType{}
+			for _, event := range publisher.events {
+				actions = append(actions, event.Action())
+				if tt.wantID != uuid.Nil && event.ID() != tt.wantID {
+					t.Errorf("event ID = %v, want %v", event.ID(), tt.wantID)
+				}
+				if version := event.AdditionalData["version"]; version != tt.wantVersion {
+					t.Errorf("event version = %v, want %v", version, tt.wantVersion)
+				}
+			}
+			if diff := cmp.Diff(tt.wantActions, actions, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("actions = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package article
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+const (
+	contentTypeNDJSON = "application/x-ndjson"
+	contentTypeCSV    = "text/csv"
+
+	// exportBatchSize is the number of articles read from the database at once during an export.
+	exportBatchSize = 500
+)
+
+var (
+	errInvalidRecord = errors.New("invalid record")
+
+	// articleCSVColumns are the columns of the CSV representation of an article.
+	articleCSVColumns = []string{
+		"id", "created_at", "updated_at", "version",
+		"title", "slug", "description", "content",
+		"published", "published_at", "published_by", "publish_at",
+		"tags", "author_id", "co_author_ids",
+	}
+)
+
+// exportArticles streams all articles matching the filters of getArticles.
+// The articles are read from the database in batches and written as they arrive,
+// so that exports do not have to fit into memory.
+// Optional query parameters:
+// - format: ndjson (default) or csv
+// - the filters of getArticles
+func (t *articleRouter) exportArticles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	var (
+		write func(*Article) error
+		flush func() error
+	)
+	switch format := query.Get("format"); format {
+	case "", "ndjson":
+		enc := json.NewEncoder(w)
+		write = func(a *Article) error { return enc.Encode(a) }
+		flush = func() error { return nil }
+		w.Header().Set("Content-Type", contentTypeNDJSON)
+		w.Header().Set("Content-Disposition", `attachment; filename="articles.ndjson"`)
+	case "csv":
+		cw := csv.NewWriter(w)
+		write = func(a *Article) error { return cw.Write(articleCSVRecord(a)) }
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+		w.Header().Set("Content-Type", contentTypeCSV)
+		w.Header().Set("Content-Disposition", `attachment; filename="articles.csv"`)
+		// the header is part of the response even if there are no articles
+		if err := cw.Write(articleCSVColumns); err != nil {
+			t.log.Error(err).Log("failed to write CSV header")
+			return
+		}
+	default:
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "unsupported export format",
+			Error:   fmt.Sprintf("unknown format %q, expected ndjson or csv", format),
+		})
+		return
+	}
+
+	// an export may take longer than the write timeout of the server
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	batch := []*Article{}
+	err := filterArticles(t.db.Find(&batch), query).Batches(ctx, exportBatchSize, func() error {
+		for _, article := range batch {
+			err := write(article)
+			if err != nil {
+				return err
+			}
+		}
+		err := flush()
+		if err != nil {
+			return err
+		}
+		rc.Flush()
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		// the status has already been sent, so the client only notices the truncated response
+		t.log.Error(err).Log("failed to export articles")
+	}
+}
+
+// articleCSVRecord returns the CSV record of article in the order of articleCSVColumns.
+func articleCSVRecord(a *Article) []string {
+	return []string{
+		a.ID.String(),
+		formatCSVTime(&a.CreatedAt),
+		formatCSVTime(&a.UpdatedAt),
+		strconv.FormatInt(a.Version, 10),
+		a.Title,
+		a.Slug,
+		a.Description,
+		a.Content,
+		strconv.FormatBool(a.Published),
+		formatCSVTime(a.PublishedAt),
+		formatCSVUUID(a.PublishedBy),
+		formatCSVTime(a.PublishAt),
+		formatCSVJSON(a.Tags),
+		a.AuthorID.String(),
+		formatCSVJSON(a.CoAuthorIDs),
+	}
+}
+
+// articleFromCSV parses a CSV record. columns are the column names of the record, as given by the CSV header.
+func articleFromCSV(columns []string, record []string) (*Article, error) {
+	if len(record) != len(columns) {
+		return nil, fmt.Errorf("%w: expected %d fields, got %d", errInvalidRecord, len(columns), len(record))
+	}
+
+	a := &Article{}
+	for i, column := range columns {
+		value := record[i]
+		if value == "" {
+			continue
+		}
+		var err error
+		switch column {
+		case "id":
+			a.ID, err = uuid.Parse(value)
+		case "created_at":
+			a.CreatedAt, err = time.Parse(time.RFC3339Nano, value)
+		case "updated_at":
+			a.UpdatedAt, err = time.Parse(time.RFC3339Nano, value)
+		case "version":
+			a.Version, err = strconv.ParseInt(value, 10, 64)
+		case "title":
+			a.Title = value
+		case "slug":
+			a.Slug = value
+		case "description":
+			a.Description = value
+		case "content":
+			a.Content = value
+		case "published":
+			a.Published, err = strconv.ParseBool(value)
+		case "published_at":
+			a.PublishedAt, err = parseCSVTime(value)
+		case "published_by":
+			a.PublishedBy, err = parseCSVUUID(value)
+		case "publish_at":
+			a.PublishAt, err = parseCSVTime(value)
+		case "tags":
+			err = json.Unmarshal([]byte(value), &a.Tags)
+		case "author_id":
+			a.AuthorID, err = uuid.Parse(value)
+		case "co_author_ids":
+			err = json.Unmarshal([]byte(value), &a.CoAuthorIDs)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: column %s: %v", errInvalidRecord, column, err)
+		}
+	}
+	return a, nil
+}
+
+// checkCSVColumns returns an error if the CSV header contains unknown or duplicate columns.
+func checkCSVColumns(columns []string) error {
+	known := map[string]bool{}
+	for _, column := range articleCSVColumns {
+		known[column] = true
+	}
+	seen := map[string]bool{}
+	for _, column := range columns {
+		if !known[column] {
+			return fmt.Errorf("unknown column %q", column)
+		}
+		if seen[column] {
+			return fmt.Errorf("duplicate column %q", column)
+		}
+		seen[column] = true
+	}
+	return nil
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseCSVTime(value string) (*time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func formatCSVUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func parseCSVUUID(value string) (*uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// formatCSVJSON returns the JSON representation of a list, or an empty string for empty lists.
+func formatCSVJSON(list any) string {
+	// marshalling lists of strings and UUIDs cannot fail
+	data, _ := json.Marshal(list)
+	if s := string(data); s != "null" && s != "[]" {
+		return s
+	}
+	return ""
+}
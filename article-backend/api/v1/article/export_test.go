@@ -0,0 +1,273 @@
+package article
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+func TestArticleCSVRecord(t *testing.T) {
+	created := time.Date(2023, 5, 1, 12, 30, 0, 123, time.UTC)
+	published := created.Add(time.Hour)
+	publisher := uuid.New()
+	tests := []struct {
+		name    string
+		article *Article
+	}{
+		{
+			name: "all fields",
+			article: &Article{
+				ID:          uuid.New(),
+				CreatedAt:   created,
+				UpdatedAt:   published,
+				Version:     3,
+				Title:       "title, with comma",
+				Slug:        "title-with-comma",
+				Description: `"quoted" description`,
+				Content:     "first line\nsecond line",
+				Published:   true,
+				PublishedAt: &published,
+				PublishedBy: &publisher,
+				Tags:        []string{"go", "web"},
+				AuthorID:    uuid.New(),
+				CoAuthorIDs: []uuid.UUID{uuid.New(), uuid.New()},
+			},
+		},
+		{
+			name: "empty fields",
+			article: &Article{
+				ID:        uuid.New(),
+				CreatedAt: created,
+				UpdatedAt: created,
+				Version:   1,
+				Title:     "title",
+				Slug:      "title",
+				PublishAt: &published,
+				AuthorID:  uuid.New(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := articleCSVRecord(tt.article)
+			if len(record) != len(articleCSVColumns) {
+				t.Fatalf("articleCSVRecord() returned %d fields, want %d", len(record), len(articleCSVColumns))
+			}
+			got, err := articleFromCSV(articleCSVColumns, record)
+			if err != nil {
+				t.Fatalf("articleFromCSV() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.article, got); diff != "" {
+				t.Errorf("articleFromCSV() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestArticleFromCSV(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		name    string
+		columns []string
+		record  []string
+		want    *Article
+		wantErr error
+	}{
+		{
+			name:    "subset of columns",
+			columns: []string{"title", "id", "tags"},
+			record:  []string{"title", id.String(), `["go"]`},
+			want:    &Article{ID: id, Title: "title", Tags: []string{"go"}},
+		},
+		{
+			name:    "empty fields",
+			columns: []string{"id", "version", "published_at"},
+			record:  []string{"", "", ""},
+			want:    &Article{},
+		},
+		{
+			name:    "missing field",
+			columns: []string{"id", "title"},
+			record:  []string{id.String()},
+			wantErr: errInvalidRecord,
+		},
+		{
+			name:    "invalid id",
+			columns: []string{"id"},
+			record:  []string{"article"},
+			wantErr: errInvalidRecord,
+		},
+		{
+			name:    "invalid time",
+			columns: []string{"published_at"},
+			record:  []string{"yesterday"},
+			wantErr: errInvalidRecord,
+		},
+		{
+			name:    "invalid tags",
+			columns: []string{"tags"},
+			record:  []string{"go,web"},
+			wantErr: errInvalidRecord,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := articleFromCSV(tt.columns, tt.record)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("articleFromCSV() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("articleFromCSV() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestCheckCSVColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		wantErr bool
+	}{
+		{
+			name:    "all columns",
+			columns: articleCSVColumns,
+		},
+		{
+			name:    "subset of columns",
+			columns: []string{"title", "author_id"},
+		},
+		{
+			name:    "unknown column",
+			columns: []string{"title", "rating"},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate column",
+			columns: []string{"title", "content", "title"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCSVColumns(tt.columns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkCSVColumns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImportArticles(t *testing.T) {
+	author := &Author{ID: uuid.New(), Name: "author"}
+	existing := &Article{ID: uuid.New(), Version: 1, Title: "existing", Slug: "existing", AuthorID: author.ID}
+	trashed := trashedArticle("trashed", time.Now())
+	trashed.AuthorID = author.ID
+	newID := uuid.New()
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		want        *ImportReport
+		// wantTitles are the titles of the articles after the import.
+		wantTitles []string
+	}{
+		{
+			name:        "ndjson",
+			contentType: contentTypeNDJSON,
+			body: `{"id": "` + existing.ID.String() + `", "title": "updated", "author_id": "` + author.ID.String() + `"}
+{"id": "` + newID.String() + `", "title": "created", "author_id": "` + author.ID.String() + `"}
+{"title": 1}
+{"title": ""}
+`,
+			wantStatus: http.StatusOK,
+			want: &ImportReport{Created: 1, Updated: 1, Failed: 2, Errors: []ImportError{
+				{Record: 3},
+				{Record: 4},
+			}},
+			wantTitles: []string{"updated", "trashed", "created"},
+		},
+		{
+			name:        "article in trash",
+			contentType: contentTypeNDJSON,
+			body:        `{"id": "` + trashed.ID.String() + `", "title": "imported", "author_id": "` + author.ID.String() + `"}`,
+			wantStatus:  http.StatusOK,
+			want: &ImportReport{Failed: 1, Errors: []ImportError{
+				{Record: 1, ID: &trashed.ID, Error: errImportTrashed.Error()},
+			}},
+			wantTitles: []string{"existing", "trashed"},
+		},
+		{
+			name:        "csv",
+			contentType: contentTypeCSV,
+			body: "id,title,author_id\n" +
+				existing.ID.String() + ",updated," + author.ID.String() + "\n" +
+				",created," + author.ID.String() + "\n" +
+				"invalid,title," + author.ID.String() + "\n",
+			wantStatus: http.StatusOK,
+			want: &ImportReport{Created: 1, Updated: 1, Failed: 1, Errors: []ImportError{
+				{Record: 3},
+			}},
+			wantTitles: []string{"updated", "trashed", "created"},
+		},
+		{
+			name:        "invalid CSV header",
+			contentType: contentTypeCSV,
+			body:        "id,rating\n",
+			wantStatus:  http.StatusBadRequest,
+			wantTitles:  []string{"existing", "trashed"},
+		},
+		{
+			name:        "unsupported format",
+			contentType: "application/json",
+			body:        `[]`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantTitles:  []string{"existing", "trashed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(author, existing, trashed)
+			repo.query = noRows
+			r := httptest.NewRequest(http.MethodPost, "/articles/import", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			w := serve(repo, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.want != nil {
+				got := &ImportReport{}
+				err := json.Unmarshal(w.Body.Bytes(), got)
+				if err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				// the IDs and errors of failed records are only compared if they are expected
+				if diff := cmp.Diff(tt.want, got, cmp.Comparer(func(a, b ImportError) bool {
+					if a.Error == "" || b.Error == "" {
+						return a.Record == b.Record
+					}
+					return cmp.Equal(a, b)
+				})); diff != "" {
+					t.Errorf("report = %v", diff)
+				}
+			}
+
+			titles := []string{}
+			for _, article := range fakeRecords[Article](repo) {
+				titles = append(titles, article.Title)
+			}
+			if diff := cmp.Diff(tt.wantTitles, titles); diff != "" {
+				t.Errorf("articles = %v", diff)
+			}
+		})
+	}
+}
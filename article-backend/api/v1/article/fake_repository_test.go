@@ -0,0 +1,949 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var errFakeUnsupported = errors.New("not supported by the fake repository")
+
+// fakeSchemas caches the schemas of the models stored in fake repositories.
+var fakeSchemas = &sync.Map{}
+
+// fakeRepository is a db.Repository holding records in memory, one table per model.
+// It understands the conditions used by this package: comparisons of a column with a placeholder, IN,
+// IS [NOT] NULL, containment of JSON arrays (@> ?::jsonb) and disjunctions of these. Soft deletes, unique
+// indexes and the hooks of the models are applied like by the database. Statements it does not understand
+// fail with errFakeUnsupported.
+type fakeRepository struct {
+	mu     sync.Mutex
+	tables map[string][]reflect.Value
+	// query answers Query and Raw. If it is nil, both fail with errFakeUnsupported.
+	query func(dest any, sql string, args ...any) error
+}
+
+// newFakeRepository returns a fakeRepository containing copies of records, which are pointers to models.
+func newFakeRepository(records ...any) *fakeRepository {
+	f := &fakeRepository{tables: map[string][]reflect.Value{}}
+	for _, record := range records {
+		sch := fakeSchema(record)
+		f.tables[sch.Table] = append(f.tables[sch.Table], fakeCopy(reflect.ValueOf(record)))
+	}
+	return f
+}
+
+// fakeRecords returns copies of the records of type T in f, including soft deleted ones, in insertion order.
+func fakeRecords[T any](f *fakeRepository) []*T {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	records := []*T{}
+	for _, record := range f.tables[fakeSchema(new(T)).Table] {
+		records = append(records, fakeCopy(record).Interface().(*T))
+	}
+	return records
+}
+
+// noRows answers all raw queries of a fakeRepository with no rows.
+func noRows(any, string, ...any) error {
+	return nil
+}
+
+// fakeSchema parses the schema of model, a pointer to a model or a slice of models.
+func fakeSchema(model any) *schema.Schema {
+	sch, err := schema.Parse(model, fakeSchemas, schema.NamingStrategy{})
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse schema of %T: %v", model, err))
+	}
+	return sch
+}
+
+func (f *fakeRepository) Create(_ context.Context, data any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sch := fakeSchema(data)
+	record := reflect.ValueOf(data)
+	if record.Kind() != reflect.Pointer || record.Elem().Kind() != reflect.Struct {
+		return errFakeUnsupported
+	}
+
+	now := time.Now()
+	for _, field := range sch.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		value, zero := field.ValueOf(context.Background(), record.Elem())
+		switch {
+		case !zero:
+		case field.PrimaryKey && field.FieldType == reflect.TypeOf(uuid.UUID{}):
+			value = uuid.New()
+		case field.AutoCreateTime > 0 || field.AutoUpdateTime > 0:
+			value = now
+		case field.DefaultValueInterface != nil:
+			value = field.DefaultValueInterface
+		default:
+			continue
+		}
+		err := field.Set(context.Background(), record.Elem(), value)
+		if err != nil {
+			return err
+		}
+	}
+
+	stored := fakeCopy(record)
+	err := f.checkUnique(sch, f.tables[sch.Table], stored)
+	if err != nil {
+		return err
+	}
+	f.tables[sch.Table] = append(f.tables[sch.Table], stored)
+	return fakeAfterSave(data)
+}
+
+func (f *fakeRepository) Find(data any) db.TX {
+	return &fakeTX{repo: f, operation: fakeFind, data: data, limit: -1}
+}
+
+func (f *fakeRepository) Update(data any) db.TX {
+	return &fakeTX{repo: f, operation: fakeUpdate, data: data, limit: -1}
+}
+
+func (f *fakeRepository) Delete(data any) db.TX {
+	return &fakeTX{repo: f, operation: fakeDelete, data: data, limit: -1}
+}
+
+func (f *fakeRepository) Count(model any, count *int64) db.TX {
+	return &fakeTX{repo: f, operation: fakeCount, data: model, count: count, limit: -1}
+}
+
+func (f *fakeRepository) Raw(_ context.Context, sql string, args ...any) error {
+	if f.query == nil {
+		return errFakeUnsupported
+	}
+	return f.query(nil, sql, args...)
+}
+
+func (f *fakeRepository) Query(_ context.Context, dest any, sql string, args ...any) error {
+	if f.query == nil {
+		return errFakeUnsupported
+	}
+	return f.query(dest, sql, args...)
+}
+
+func (f *fakeRepository) Migrate(context.Context, any) error {
+	return errFakeUnsupported
+}
+
+// Transaction runs fn on f and restores the records of f if fn fails.
+// Transactions are not isolated from each other.
+func (f *fakeRepository) Transaction(_ context.Context, fn func(tx db.Repository) error, _ ...db.TxOptions) error {
+	f.mu.Lock()
+	snapshot := make(map[string][]reflect.Value, len(f.tables))
+	for table, records := range f.tables {
+		for _, record := range records {
+			snapshot[table] = append(snapshot[table], fakeCopy(record))
+		}
+	}
+	f.mu.Unlock()
+
+	err := fn(f)
+	if err != nil {
+		f.mu.Lock()
+		f.tables = snapshot
+		f.mu.Unlock()
+	}
+	return err
+}
+
+func (f *fakeRepository) Close(context.Context) error {
+	return nil
+}
+
+// checkUnique returns a conflict if record has the primary key or the values of a unique index of
+// another record of records.
+func (f *fakeRepository) checkUnique(sch *schema.Schema, records []reflect.Value, record reflect.Value) error {
+	constraints := map[string][]*schema.Field{sch.Table + "_pkey": sch.PrimaryFields}
+	for _, index := range sch.ParseIndexes() {
+		if index.Class != "UNIQUE" {
+			continue
+		}
+		for _, option := range index.Fields {
+			constraints[index.Name] = append(constraints[index.Name], option.Field)
+		}
+	}
+
+	for name, fields := range constraints {
+		for _, other := range records {
+			if other.Pointer() == record.Pointer() || fakePrimaryKeyEqual(sch, other, record) {
+				continue
+			}
+			equal := true
+			for _, field := range fields {
+				a := fakeColumn(field, other)
+				b := fakeColumn(field, record)
+				if a == nil || b == nil || fakeCompare(a, b) != 0 {
+					equal = false
+					break
+				}
+			}
+			if equal {
+				return fmt.Errorf("%w: %w", db.ErrConflict, &pgconn.PgError{Code: "23505", ConstraintName: name})
+			}
+		}
+	}
+	return nil
+}
+
+type fakeOperation int
+
+const (
+	fakeFind fakeOperation = iota
+	fakeUpdate
+	fakeDelete
+	fakeCount
+)
+
+// fakeCondition reports whether a record of the schema matches a condition.
+type fakeCondition func(sch *schema.Schema, record reflect.Value) bool
+
+type fakeOrder struct {
+	column string
+	desc   bool
+}
+
+// fakeTX is a statement on the records of a fakeRepository.
+type fakeTX struct {
+	repo      *fakeRepository
+	operation fakeOperation
+	data      any
+	count     *int64
+	// conditions is a disjunction of conjunctions. Where and Not add to the last conjunction, Or starts a new one.
+	conditions   [][]fakeCondition
+	selects      []string
+	orders       []fakeOrder
+	limit        int
+	offset       int
+	unscoped     bool
+	requireMatch bool
+	err          error
+}
+
+func (f *fakeTX) Where(query string, args ...any) db.TX {
+	return f.and(query, args, false)
+}
+
+func (f *fakeTX) Or(query string, args ...any) db.TX {
+	f.conditions = append(f.conditions, nil)
+	return f.and(query, args, false)
+}
+
+func (f *fakeTX) Not(query string, args ...any) db.TX {
+	return f.and(query, args, true)
+}
+
+func (f *fakeTX) and(query string, args []any, negate bool) db.TX {
+	condition, err := fakeParseCondition(query, args)
+	if err != nil {
+		f.err = err
+		return f
+	}
+	if negate {
+		matches := condition
+		condition = func(sch *schema.Schema, record reflect.Value) bool {
+			return !matches(sch, record)
+		}
+	}
+	if len(f.conditions) == 0 {
+		f.conditions = append(f.conditions, nil)
+	}
+	last := len(f.conditions) - 1
+	f.conditions[last] = append(f.conditions[last], condition)
+	return f
+}
+
+func (f *fakeTX) Select(query string, args ...any) db.TX {
+	columns := []string{query}
+	for _, arg := range args {
+		column, ok := arg.(string)
+		if !ok {
+			f.err = errFakeUnsupported
+			return f
+		}
+		columns = append(columns, column)
+	}
+	for _, column := range columns {
+		if !fakeIdentifier.MatchString(column) {
+			f.err = fmt.Errorf("%w: select %q", errFakeUnsupported, column)
+			return f
+		}
+	}
+	f.selects = append(f.selects, columns...)
+	return f
+}
+
+func (f *fakeTX) Order(column string, desc bool) db.TX {
+	f.orders = append(f.orders, fakeOrder{column: column, desc: desc})
+	return f
+}
+
+func (f *fakeTX) Limit(limit int) db.TX {
+	f.limit = limit
+	return f
+}
+
+func (f *fakeTX) Offset(offset int) db.TX {
+	f.offset = offset
+	return f
+}
+
+func (f *fakeTX) ForUpdate(bool) db.TX { return f }
+func (f *fakeTX) ForKeyShare() db.TX   { return f }
+
+func (f *fakeTX) Unscoped() db.TX {
+	f.unscoped = true
+	return f
+}
+
+func (f *fakeTX) RequireMatch() db.TX {
+	f.requireMatch = true
+	return f
+}
+
+// Batches reads all matching records ordered by primary key in batches of size records.
+func (f *fakeTX) Batches(ctx context.Context, size int, fn func() error) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.operation != fakeFind || len(f.orders) > 0 {
+		return errFakeUnsupported
+	}
+	sch := fakeSchema(f.data)
+	f.orders = []fakeOrder{{column: sch.PrioritizedPrimaryField.DBName}}
+	for offset := 0; ; offset += size {
+		dest := reflect.ValueOf(f.data).Elem()
+		dest.Set(reflect.MakeSlice(dest.Type(), 0, size))
+		f.offset, f.limit = offset, size
+		err := f.Commit(ctx)
+		if err != nil {
+			return err
+		}
+		if dest.Len() == 0 {
+			return nil
+		}
+		err = fn()
+		if err != nil {
+			return err
+		}
+		if dest.Len() < size {
+			return nil
+		}
+	}
+}
+
+func (f *fakeTX) Commit(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.err != nil {
+		return f.err
+	}
+	f.repo.mu.Lock()
+	defer f.repo.mu.Unlock()
+
+	var affected int
+	var err error
+	switch f.operation {
+	case fakeUpdate:
+		affected, err = f.update()
+	case fakeDelete:
+		affected, err = f.delete()
+	case fakeCount:
+		affected = len(f.matches(fakeSchema(f.data), f.data))
+		*f.count = int64(affected)
+	default:
+		affected, err = f.find()
+	}
+	if err != nil {
+		return err
+	}
+	if f.requireMatch && affected == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+// matches returns the records matching the conditions of the statement, and the primary key of data if set.
+func (f *fakeTX) matches(sch *schema.Schema, data any) []reflect.Value {
+	var key reflect.Value
+	if v := reflect.ValueOf(data); v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Struct {
+		if _, zero := sch.PrioritizedPrimaryField.ValueOf(context.Background(), v.Elem()); !zero {
+			key = v
+		}
+	}
+	deletedAt := sch.LookUpField("deleted_at")
+	softDelete := deletedAt != nil && deletedAt.FieldType == reflect.TypeOf(gorm.DeletedAt{})
+
+	matches := []reflect.Value{}
+	for _, record := range f.repo.tables[sch.Table] {
+		if softDelete && !f.unscoped && fakeColumn(deletedAt, record) != nil {
+			continue
+		}
+		if key.IsValid() && !fakePrimaryKeyEqual(sch, key, record) {
+			continue
+		}
+		if f.match(sch, record) {
+			matches = append(matches, record)
+		}
+	}
+	return matches
+}
+
+func (f *fakeTX) match(sch *schema.Schema, record reflect.Value) bool {
+	if len(f.conditions) == 0 {
+		return true
+	}
+	for _, conjunction := range f.conditions {
+		matches := true
+		for _, condition := range conjunction {
+			if !condition(sch, record) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeTX) find() (int, error) {
+	dest := reflect.ValueOf(f.data)
+	if dest.Kind() != reflect.Pointer || dest.Elem().Kind() != reflect.Slice {
+		return 0, errFakeUnsupported
+	}
+	sch := fakeSchema(f.data)
+	for _, column := range append(append([]string{}, f.selects...), f.orderColumns()...) {
+		if sch.LookUpField(column) == nil {
+			return 0, fmt.Errorf("%w: unknown column %q", errFakeUnsupported, column)
+		}
+	}
+
+	matches := f.matches(sch, f.data)
+	sort.SliceStable(matches, func(i, j int) bool {
+		for _, order := range f.orders {
+			field := sch.LookUpField(order.column)
+			c := fakeCompareNullsLast(fakeColumn(field, matches[i]), fakeColumn(field, matches[j]))
+			if order.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	if f.offset > 0 {
+		matches = matches[fakeMin(f.offset, len(matches)):]
+	}
+	if f.limit >= 0 {
+		matches = matches[:fakeMin(f.limit, len(matches))]
+	}
+
+	slice := dest.Elem()
+	for _, record := range matches {
+		result := fakeCopy(record)
+		if len(f.selects) > 0 {
+			result = reflect.New(record.Elem().Type())
+			for _, column := range f.selects {
+				field := sch.LookUpField(column)
+				err := field.Set(context.Background(), result.Elem(), field.ReflectValueOf(context.Background(), record.Elem()).Interface())
+				if err != nil {
+					return 0, err
+				}
+			}
+		}
+		if hook, ok := result.Interface().(interface{ AfterFind(*gorm.DB) error }); ok {
+			err := hook.AfterFind(nil)
+			if err != nil {
+				return 0, err
+			}
+		}
+		if slice.Type().Elem().Kind() != reflect.Pointer {
+			result = result.Elem()
+		}
+		slice = reflect.Append(slice, result)
+	}
+	dest.Elem().Set(slice)
+	return len(matches), nil
+}
+
+func (f *fakeTX) orderColumns() []string {
+	columns := []string{}
+	for _, order := range f.orders {
+		columns = append(columns, order.column)
+	}
+	return columns
+}
+
+// update writes the selected fields of data, or its non-zero fields, to the matching records.
+func (f *fakeTX) update() (int, error) {
+	data := reflect.ValueOf(f.data)
+	if data.Kind() != reflect.Pointer || data.Elem().Kind() != reflect.Struct {
+		return 0, errFakeUnsupported
+	}
+	sch := fakeSchema(f.data)
+
+	fields := []*schema.Field{}
+	if len(f.selects) > 0 {
+		for _, column := range f.selects {
+			field := sch.LookUpField(column)
+			if field == nil {
+				return 0, fmt.Errorf("%w: unknown column %q", errFakeUnsupported, column)
+			}
+			fields = append(fields, field)
+		}
+	}
+	for _, field := range sch.Fields {
+		if field.DBName == "" || field.PrimaryKey {
+			continue
+		}
+		if field.AutoUpdateTime > 0 {
+			err := field.Set(context.Background(), data.Elem(), time.Now())
+			if err != nil {
+				return 0, err
+			}
+			fields = append(fields, field)
+			continue
+		}
+		if _, zero := field.ValueOf(context.Background(), data.Elem()); len(f.selects) == 0 && !zero {
+			fields = append(fields, field)
+		}
+	}
+
+	matches := f.matches(sch, f.data)
+	updated := make([]reflect.Value, len(matches))
+	for i, record := range matches {
+		updated[i] = fakeCopy(record)
+		for _, field := range fields {
+			value := fakeCopy(field.ReflectValueOf(context.Background(), data.Elem()))
+			err := field.Set(context.Background(), updated[i].Elem(), value.Interface())
+			if err != nil {
+				return 0, err
+			}
+		}
+		err := f.repo.checkUnique(sch, f.repo.tables[sch.Table], updated[i])
+		if err != nil {
+			return 0, err
+		}
+	}
+	for i, record := range matches {
+		record.Elem().Set(updated[i].Elem())
+	}
+	return len(matches), fakeAfterSave(f.data)
+}
+
+// delete soft deletes the matching records of types with a gorm.DeletedAt field, unless the statement is
+// unscoped, and removes them otherwise.
+func (f *fakeTX) delete() (int, error) {
+	sch := fakeSchema(f.data)
+	matches := f.matches(sch, f.data)
+	deletedAt := sch.LookUpField("deleted_at")
+	if !f.unscoped && deletedAt != nil && deletedAt.FieldType == reflect.TypeOf(gorm.DeletedAt{}) {
+		for _, record := range matches {
+			err := deletedAt.Set(context.Background(), record.Elem(), time.Now())
+			if err != nil {
+				return 0, err
+			}
+		}
+		return len(matches), nil
+	}
+
+	deleted := map[uintptr]bool{}
+	for _, record := range matches {
+		deleted[record.Pointer()] = true
+	}
+	remaining := []reflect.Value{}
+	for _, record := range f.repo.tables[sch.Table] {
+		if !deleted[record.Pointer()] {
+			remaining = append(remaining, record)
+		}
+	}
+	f.repo.tables[sch.Table] = remaining
+	return len(matches), nil
+}
+
+func fakeMin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// fakeAfterSave calls the AfterSave hook of data, if it has one.
+func fakeAfterSave(data any) error {
+	if hook, ok := data.(interface{ AfterSave(*gorm.DB) error }); ok {
+		return hook.AfterSave(nil)
+	}
+	return nil
+}
+
+var (
+	fakeIdentifier = regexp.MustCompile(`^\w+$`)
+	fakeComparison = regexp.MustCompile(`^(\w+)\s*(=|<>|!=|<=|>=|<|>)\s*(\?|'[^']*')$`)
+	fakeIn         = regexp.MustCompile(`^(\w+) IN \?$`)
+	fakeNull       = regexp.MustCompile(`^(\w+) IS (NOT )?NULL$`)
+	fakeContains   = regexp.MustCompile(`^(\w+) @> \?::jsonb$`)
+	fakeRow        = regexp.MustCompile(`^\(([\w, ]+)\) (<|>) \(([?, ]+)\)$`)
+)
+
+// fakeParseCondition parses a condition of a where clause with its arguments.
+func fakeParseCondition(query string, args []any) (fakeCondition, error) {
+	query = strings.TrimSpace(query)
+	for fakeEnclosed(query) {
+		query = strings.TrimSpace(query[1 : len(query)-1])
+	}
+
+	for _, operator := range []string{" OR ", " AND "} {
+		parts := fakeSplit(query, operator)
+		if len(parts) == 1 {
+			continue
+		}
+		conditions := []fakeCondition{}
+		for _, part := range parts {
+			n := strings.Count(part, "?")
+			if n > len(args) {
+				return nil, fmt.Errorf("%w: too few arguments for %q", errFakeUnsupported, query)
+			}
+			condition, err := fakeParseCondition(part, args[:n])
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, condition)
+			args = args[n:]
+		}
+		or := operator == " OR "
+		return func(sch *schema.Schema, record reflect.Value) bool {
+			for _, condition := range conditions {
+				if condition(sch, record) == or {
+					return or
+				}
+			}
+			return !or
+		}, nil
+	}
+
+	if m := fakeRow.FindStringSubmatch(query); m != nil {
+		// (a, b) > (?, ?) is rewritten to (a > ?) OR (a = ? AND b > ?)
+		columns := strings.Split(m[1], ",")
+		if len(columns) != len(args) {
+			return nil, fmt.Errorf("%w: %q takes %d arguments", errFakeUnsupported, query, len(columns))
+		}
+		conditions := []string{}
+		expanded := []any{}
+		for i, c := range columns {
+			parts := []string{}
+			for j := 0; j < i; j++ {
+				parts = append(parts, strings.TrimSpace(columns[j])+" = ?")
+				expanded = append(expanded, args[j])
+			}
+			parts = append(parts, strings.TrimSpace(c)+" "+m[2]+" ?")
+			expanded = append(expanded, args[i])
+			conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+		}
+		return fakeParseCondition(strings.Join(conditions, " OR "), expanded)
+	}
+
+	column := func(sch *schema.Schema, record reflect.Value, name string) any {
+		field := sch.LookUpField(name)
+		if field == nil {
+			panic(fmt.Sprintf("unknown column %q of table %s", name, sch.Table))
+		}
+		return fakeColumn(field, record)
+	}
+	if m := fakeComparison.FindStringSubmatch(query); m != nil {
+		var arg any = strings.Trim(m[3], "'")
+		if m[3] == "?" {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("%w: %q takes one argument", errFakeUnsupported, query)
+			}
+			arg = fakeNormalize(args[0])
+		}
+		return func(sch *schema.Schema, record reflect.Value) bool {
+			value := column(sch, record, m[1])
+			if value == nil || arg == nil {
+				return false
+			}
+			c := fakeCompare(value, arg)
+			switch m[2] {
+			case "=":
+				return c == 0
+			case "<>", "!=":
+				return c != 0
+			case "<":
+				return c < 0
+			case "<=":
+				return c <= 0
+			case ">":
+				return c > 0
+			default:
+				return c >= 0
+			}
+		}, nil
+	}
+	if m := fakeIn.FindStringSubmatch(query); m != nil && len(args) == 1 {
+		values := reflect.ValueOf(args[0])
+		if values.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("%w: %q takes a slice", errFakeUnsupported, query)
+		}
+		return func(sch *schema.Schema, record reflect.Value) bool {
+			value := column(sch, record, m[1])
+			for i := 0; i < values.Len(); i++ {
+				arg := fakeNormalize(values.Index(i).Interface())
+				if value != nil && arg != nil && fakeCompare(value, arg) == 0 {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	if m := fakeNull.FindStringSubmatch(query); m != nil && len(args) == 0 {
+		return func(sch *schema.Schema, record reflect.Value) bool {
+			return (column(sch, record, m[1]) == nil) == (m[2] == "")
+		}, nil
+	}
+	if m := fakeContains.FindStringSubmatch(query); m != nil && len(args) == 1 {
+		want := []any{}
+		err := json.Unmarshal([]byte(fmt.Sprint(args[0])), &want)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q takes a JSON array: %w", errFakeUnsupported, query, err)
+		}
+		return func(sch *schema.Schema, record reflect.Value) bool {
+			data, err := json.Marshal(column(sch, record, m[1]))
+			if err != nil {
+				return false
+			}
+			elements := []any{}
+			if json.Unmarshal(data, &elements) != nil {
+				return false
+			}
+			for _, w := range want {
+				found := false
+				for _, element := range elements {
+					if reflect.DeepEqual(element, w) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					return false
+				}
+			}
+			return true
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: condition %q", errFakeUnsupported, query)
+}
+
+// fakeEnclosed reports whether query is enclosed in a pair of parentheses.
+func fakeEnclosed(query string) bool {
+	if !strings.HasPrefix(query, "(") || !strings.HasSuffix(query, ")") {
+		return false
+	}
+	depth := 0
+	for i, r := range query {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i < len(query)-1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// fakeSplit splits query at operator outside of parentheses.
+func fakeSplit(query, operator string) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	for i := 0; i < len(query); i++ {
+		switch query[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 && strings.HasPrefix(query[i:], operator) {
+				parts = append(parts, query[start:i])
+				start = i + len(operator)
+				i = start - 1
+			}
+		}
+	}
+	return append(parts, query[start:])
+}
+
+// fakeColumn returns the normalized value of field of record.
+func fakeColumn(field *schema.Field, record reflect.Value) any {
+	return fakeNormalize(field.ReflectValueOf(context.Background(), record.Elem()).Interface())
+}
+
+func fakePrimaryKeyEqual(sch *schema.Schema, a, b reflect.Value) bool {
+	for _, field := range sch.PrimaryFields {
+		if fakeCompare(fakeColumn(field, a), fakeColumn(field, b)) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// fakeNormalize returns v as it is compared by the database: nil for NULL, without pointers, numbers as
+// float64 and UUIDs as strings.
+func fakeNormalize(v any) any {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case gorm.DeletedAt:
+		if !value.Valid {
+			return nil
+		}
+		return value.Time
+	case uuid.UUID:
+		return value.String()
+	case time.Time:
+		return value
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil
+		}
+		return fakeNormalize(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	default:
+		return v
+	}
+}
+
+// fakeCompare compares normalized values of the same type. Values of other types are compared as JSON.
+func fakeCompare(a, b any) int {
+	switch x := a.(type) {
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			default:
+				return 0
+			}
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			// the database casts strings compared with UUIDs, which accepts all spellings of a UUID
+			if u, err := uuid.Parse(x); err == nil {
+				if v, err := uuid.Parse(y); err == nil {
+					return strings.Compare(u.String(), v.String())
+				}
+			}
+			return strings.Compare(x, y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case y:
+				return -1
+			default:
+				return 1
+			}
+		}
+	}
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return strings.Compare(string(x), string(y))
+}
+
+// fakeCompareNullsLast compares normalized values, ordering NULL after all other values like the database.
+func fakeCompareNullsLast(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	default:
+		return fakeCompare(a, b)
+	}
+}
+
+// fakeCopy returns a deep copy of v.
+func fakeCopy(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			p := reflect.New(v.Type().Elem())
+			p.Elem().Set(fakeCopy(v.Elem()))
+			c.Set(p)
+		}
+	case reflect.Struct:
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(fakeCopy(v.Field(i)))
+			}
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			c.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				c.Index(i).Set(fakeCopy(v.Index(i)))
+			}
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			c.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+			for _, key := range v.MapKeys() {
+				c.SetMapIndex(key, fakeCopy(v.MapIndex(key)))
+			}
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			c.Set(fakeCopy(v.Elem()))
+		}
+	default:
+		c.Set(v)
+	}
+	return c
+}
@@ -0,0 +1,330 @@
+package article
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leonsteinhaeuser/example-app/internal/markdown"
+)
+
+const (
+	// DefaultFeedTitle is the title of the feeds if none is configured.
+	DefaultFeedTitle = "Articles"
+
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+
+	atomNamespace = "http://www.w3.org/2005/Atom"
+)
+
+// WithFeed sets the title of the Atom and RSS feeds and the URL the articles of the feeds link to.
+// The slug of an article is appended to articleURL, e.g. https://example.com/articles becomes
+// https://example.com/articles/my-article. If articleURL is empty, the articles link to the by-slug endpoint
+// on the host of the feed request.
+func WithFeed(title string, articleURL string) Option {
+	return func(t *articleRouter) {
+		if title == "" {
+			title = DefaultFeedTitle
+		}
+		t.feedTitle = title
+		t.feedArticleURL = strings.TrimSuffix(articleURL, "/")
+	}
+}
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"feed"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	ID      string       `xml:"id"`
+	Title   string       `xml:"title"`
+	Updated string       `xml:"updated"`
+	Links   []atomLink   `xml:"link"`
+	Author  atomPerson   `xml:"author"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type rssFeed struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomXMLNS string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	AtomLink      atomLink   `xml:"atom:link"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description,omitempty"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// feedArticles reads the latest published articles for a feed request.
+// It accepts the filters of getArticles, except for published, and returns the time the feed was last modified,
+// which is the latest change of any article, including articles that were unpublished or deleted.
+func (t *articleRouter) feedArticles(r *http.Request) ([]*Article, time.Time, error) {
+	query := r.URL.Query()
+	query.Del("published")
+
+	limit := defaultFeedLimit
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > maxFeedLimit {
+		limit = maxFeedLimit
+	}
+
+	articles := []*Article{}
+	err := filterArticles(t.db.Find(&articles), query).
+		Where("published = ?", true).
+		// articles published before the publication time was recorded would be sorted first
+		Where("published_at IS NOT NULL").
+		Order("published_at", true).
+		Order("id", true).
+		Limit(limit).
+		Commit(r.Context())
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	// articles leaving the feed change it as well, so the modification time must not be derived from the
+	// articles still in it, or it would move backwards and clients with a stale copy would not get the change
+	changed := []*time.Time{}
+	err = t.db.Query(r.Context(), &changed, `SELECT max(greatest(updated_at, deleted_at)) FROM articles`)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	modified := time.Time{}
+	if len(changed) > 0 && changed[0] != nil {
+		modified = *changed[0]
+	}
+	for _, article := range articles {
+		if updated := articleUpdated(article); updated.After(modified) {
+			modified = updated
+		}
+	}
+	return articles, modified, nil
+}
+
+// articleUpdated returns the time an article was last changed in a way visible in the feeds.
+func articleUpdated(article *Article) time.Time {
+	if article.PublishedAt != nil && article.PublishedAt.After(article.UpdatedAt) {
+		return *article.PublishedAt
+	}
+	return article.UpdatedAt
+}
+
+// checkModified sets the Last-Modified header of a feed and returns false if the client has a current copy,
+// in which case the response has been written.
+func checkModified(w http.ResponseWriter, r *http.Request, modified time.Time) bool {
+	if modified.IsZero() {
+		return true
+	}
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	// the headers have a precision of seconds, so the fractions of modified must be ignored
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && modified.Unix() <= since.Unix() {
+		w.WriteHeader(http.StatusNotModified)
+		return false
+	}
+	return true
+}
+
+// requestURL returns the absolute URL of r. Behind a proxy, the scheme is read from X-Forwarded-Proto.
+func requestURL(r *http.Request) *url.URL {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return &url.URL{
+		Scheme:   scheme,
+		Host:     r.Host,
+		Path:     r.URL.Path,
+		RawQuery: r.URL.RawQuery,
+	}
+}
+
+// articleLinkBase returns the URL the slugs of the articles of a feed are appended to.
+func (t *articleRouter) articleLinkBase(r *http.Request) string {
+	if t.feedArticleURL != "" {
+		return t.feedArticleURL
+	}
+	base := requestURL(r)
+	base.Path = "/articles/by-slug"
+	base.RawQuery = ""
+	return base.String()
+}
+
+// writeFeed writes feed as XML document with the given content type.
+func (t *articleRouter) writeFeed(w http.ResponseWriter, contentType string, feed any) {
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		t.writeError(w, "failed to encode feed", err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, xml.Header)
+	w.Write(data)
+}
+
+// getAtomFeed returns the latest published articles as Atom feed.
+// query parameters:
+// - limit: int (default: 20, max: 100)
+// - tags, match, author_id, co_author_id, contributor_id: filters as for getArticles
+func (t *articleRouter) getAtomFeed(w http.ResponseWriter, r *http.Request) {
+	articles, modified, err := t.feedArticles(r)
+	if err != nil {
+		t.writeError(w, "failed to get feed", err)
+		return
+	}
+	if !checkModified(w, r, modified) {
+		return
+	}
+
+	self := requestURL(r).String()
+	base := t.articleLinkBase(r)
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	feed := atomFeed{
+		XMLNS:   atomNamespace,
+		ID:      self,
+		Title:   t.feedTitle,
+		Updated: modified.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: self, Rel: "self", Type: "application/atom+xml"},
+			{Href: base, Rel: "alternate"},
+		},
+		Author:  atomPerson{Name: t.feedTitle},
+		Entries: make([]*atomEntry, 0, len(articles)),
+	}
+	for _, article := range articles {
+		content, err := markdown.Parse(article.Content).HTML()
+		if err != nil {
+			t.writeError(w, "failed to render feed", err)
+			return
+		}
+		entry := &atomEntry{
+			ID:      "urn:uuid:" + article.ID.String(),
+			Title:   article.Title,
+			Updated: articleUpdated(article).UTC().Format(time.RFC3339),
+			Links:   []atomLink{{Href: base + "/" + article.Slug, Rel: "alternate", Type: "text/html"}},
+			Content: &atomText{Type: "html", Body: content},
+		}
+		if article.PublishedAt != nil {
+			entry.Published = article.PublishedAt.UTC().Format(time.RFC3339)
+		}
+		if summary := articleSummary(article); summary != "" {
+			entry.Summary = &atomText{Type: "text", Body: summary}
+		}
+		for _, tag := range article.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	t.writeFeed(w, "application/atom+xml; charset=utf-8", feed)
+}
+
+// getRSSFeed returns the latest published articles as RSS 2.0 feed.
+// It accepts the query parameters of getAtomFeed.
+func (t *articleRouter) getRSSFeed(w http.ResponseWriter, r *http.Request) {
+	articles, modified, err := t.feedArticles(r)
+	if err != nil {
+		t.writeError(w, "failed to get feed", err)
+		return
+	}
+	if !checkModified(w, r, modified) {
+		return
+	}
+
+	base := t.articleLinkBase(r)
+	feed := rssFeed{
+		Version:   "2.0",
+		AtomXMLNS: atomNamespace,
+		Channel: rssChannel{
+			Title:       t.feedTitle,
+			Link:        base,
+			Description: t.feedTitle,
+			AtomLink:    atomLink{Href: requestURL(r).String(), Rel: "self", Type: "application/rss+xml"},
+			Items:       make([]*rssItem, 0, len(articles)),
+		},
+	}
+	if !modified.IsZero() {
+		feed.Channel.LastBuildDate = modified.UTC().Format(time.RFC1123Z)
+	}
+	for _, article := range articles {
+		item := &rssItem{
+			Title:       article.Title,
+			Link:        base + "/" + article.Slug,
+			Description: articleSummary(article),
+			GUID:        rssGUID{Value: "urn:uuid:" + article.ID.String()},
+			Categories:  article.Tags,
+		}
+		if article.PublishedAt != nil {
+			item.PubDate = article.PublishedAt.UTC().Format(time.RFC1123Z)
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	t.writeFeed(w, "application/rss+xml; charset=utf-8", feed)
+}
+
+// articleSummary returns the description of an article, or its excerpt if it has none.
+func articleSummary(article *Article) string {
+	if article.Description != "" {
+		return article.Description
+	}
+	return article.Excerpt
+}
@@ -0,0 +1,156 @@
+package article
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestCheckModified(t *testing.T) {
+	modified := time.Date(2024, 5, 17, 9, 30, 12, 345678000, time.FixedZone("CEST", 2*60*60))
+	tests := []struct {
+		name            string
+		ifModifiedSince string
+		modified        time.Time
+		want            bool
+		wantStatus      int
+		wantHeader      string
+	}{
+		{
+			name:       "no conditional request",
+			modified:   modified,
+			want:       true,
+			wantStatus: http.StatusOK,
+			wantHeader: "Fri, 17 May 2024 07:30:12 GMT",
+		},
+		{
+			name:            "unchanged since the last response",
+			ifModifiedSince: "Fri, 17 May 2024 07:30:12 GMT",
+			modified:        modified,
+			want:            false,
+			wantStatus:      http.StatusNotModified,
+			wantHeader:      "Fri, 17 May 2024 07:30:12 GMT",
+		},
+		{
+			name:            "modified since",
+			ifModifiedSince: "Fri, 17 May 2024 07:30:11 GMT",
+			modified:        modified,
+			want:            true,
+			wantStatus:      http.StatusOK,
+			wantHeader:      "Fri, 17 May 2024 07:30:12 GMT",
+		},
+		{
+			name:            "invalid date",
+			ifModifiedSince: "yesterday",
+			modified:        modified,
+			want:            true,
+			wantStatus:      http.StatusOK,
+			wantHeader:      "Fri, 17 May 2024 07:30:12 GMT",
+		},
+		{
+			name:            "empty feed",
+			ifModifiedSince: "Fri, 17 May 2024 07:30:12 GMT",
+			want:            true,
+			wantStatus:      http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/articles/feeds/atom.xml", nil)
+			if tt.ifModifiedSince != "" {
+				r.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			}
+			w := httptest.NewRecorder()
+
+			if got := checkModified(w, r, tt.modified); got != tt.want {
+				t.Errorf("checkModified() = %v, want %v", got, tt.want)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("checkModified() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Last-Modified"); got != tt.wantHeader {
+				t.Errorf("checkModified() Last-Modified = %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestFeedLastModified(t *testing.T) {
+	published := time.Date(2024, 5, 17, 7, 30, 12, 0, time.UTC)
+	later := published.Add(time.Hour)
+	article := func(updated time.Time, isPublished bool, deleted time.Time) *Article {
+		a := &Article{ID: uuid.New(), UpdatedAt: updated, Version: 1, Title: "feed", Slug: uuid.NewString()}
+		if isPublished {
+			a.Published = true
+			a.PublishedAt = &published
+		}
+		if !deleted.IsZero() {
+			a.DeletedAt = gorm.DeletedAt{Time: deleted, Valid: true}
+		}
+		return a
+	}
+	tests := []struct {
+		name       string
+		articles   []*Article
+		wantStatus int
+		wantHeader string
+	}{
+		{
+			name:       "unchanged",
+			articles:   []*Article{article(published, true, time.Time{})},
+			wantStatus: http.StatusNotModified,
+			wantHeader: "Fri, 17 May 2024 07:30:12 GMT",
+		},
+		{
+			name:       "article unpublished",
+			articles:   []*Article{article(published, true, time.Time{}), article(later, false, time.Time{})},
+			wantStatus: http.StatusOK,
+			wantHeader: "Fri, 17 May 2024 08:30:12 GMT",
+		},
+		{
+			name:       "article deleted",
+			articles:   []*Article{article(published, true, time.Time{}), article(published, true, later)},
+			wantStatus: http.StatusOK,
+			wantHeader: "Fri, 17 May 2024 08:30:12 GMT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := []any{}
+			for _, article := range tt.articles {
+				records = append(records, article)
+			}
+			repo := newFakeRepository(records...)
+			repo.query = func(dest any, _ string, _ ...any) error {
+				// the latest change of any article, as computed by the database
+				var latest *time.Time
+				for _, article := range fakeRecords[Article](repo) {
+					for _, changed := range []time.Time{article.UpdatedAt, article.DeletedAt.Time} {
+						changed := changed
+						if latest == nil || changed.After(*latest) {
+							latest = &changed
+						}
+					}
+				}
+				*dest.(*[]*time.Time) = []*time.Time{latest}
+				return nil
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/feeds/atom.xml", nil)
+			// the client has the feed as it was when the articles were published
+			r.Header.Set("If-Modified-Since", "Fri, 17 May 2024 07:30:12 GMT")
+			w := serve(repo, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := w.Header().Get("Last-Modified"); got != tt.wantHeader {
+				t.Errorf("Last-Modified = %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package article
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+)
+
+var (
+	errInvalidFields = errors.New("invalid fields")
+)
+
+// articleFieldColumns maps the fields of an article, as named in JSON, to the columns they are read from.
+// The derived fields are computed from the content, so they need it to be read.
+// The embedded author profiles need the IDs of the authors.
+var articleFieldColumns = map[string][]string{
+	"id":            {"id"},
+	"created_at":    {"created_at"},
+	"updated_at":    {"updated_at"},
+	"deleted_at":    {"deleted_at"},
+	"version":       {"version"},
+	"title":         {"title"},
+	"slug":          {"slug"},
+	"description":   {"description"},
+	"content":       {"content"},
+	"excerpt":       {"content"},
+	"word_count":    {"content"},
+	"reading_time":  {"content"},
+	"content_html":  {"content"},
+	"published":     {"published"},
+	"published_at":  {"published_at"},
+	"published_by":  {"published_by"},
+	"publish_at":    {"publish_at"},
+	"tags":          {"tags"},
+	"author_id":     {"author_id"},
+	"co_author_ids": {"co_author_ids"},
+	"author":        {"author_id"},
+	"co_authors":    {"co_author_ids"},
+}
+
+// defaultListExclude are the fields left out of list responses, unless requested with fields or exclude.
+// Lists leave out the content and the fields derived from it, so that the content is not read.
+var defaultListExclude = []string{"content", "excerpt", "word_count", "reading_time", "content_html"}
+
+// renderedListFields are the fields included in list responses by default if the content is rendered.
+var renderedListFields = map[string]bool{"content": true, "content_html": true}
+
+// parseListFieldSet returns the fields of the articles of a list response requested with query.
+// Without the query parameters fields and exclude, defaultListExclude is left out, unless render=html
+// requests the rendered content, which is then included together with the content it is rendered from.
+func parseListFieldSet(query url.Values) (fieldSet, error) {
+	exclude := defaultListExclude
+	if render, _ := renderRequested(query); render {
+		exclude = []string{}
+		for _, field := range defaultListExclude {
+			if !renderedListFields[field] {
+				exclude = append(exclude, field)
+			}
+		}
+	}
+	return parseFieldSet(query, exclude...)
+}
+
+// fieldSet is the set of fields of an article included in a response.
+// A nil fieldSet includes all fields.
+type fieldSet map[string]bool
+
+// parseFieldSet returns the fields requested with the query parameters fields and exclude.
+// fields is a comma separated list of the included fields, exclude one of the fields left out.
+// If neither is given, all fields except for defaultExclude are included.
+// An empty exclude parameter includes all fields.
+func parseFieldSet(query url.Values, defaultExclude ...string) (fieldSet, error) {
+	if !query.Has("fields") && !query.Has("exclude") {
+		return newFieldSet(nil, defaultExclude)
+	}
+	return newFieldSet(splitFields(query.Get("fields")), splitFields(query.Get("exclude")))
+}
+
+// newFieldSet returns the set of include without exclude. All fields are included if include is empty.
+func newFieldSet(include []string, exclude []string) (fieldSet, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	if len(include) == 0 {
+		include = make([]string, 0, len(articleFieldColumns))
+		for field := range articleFieldColumns {
+			include = append(include, field)
+		}
+	}
+
+	fields := make(fieldSet, len(include))
+	for _, field := range include {
+		if _, ok := articleFieldColumns[field]; !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", errInvalidFields, field)
+		}
+		fields[field] = true
+	}
+	for _, field := range exclude {
+		if _, ok := articleFieldColumns[field]; !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", errInvalidFields, field)
+		}
+		delete(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: all fields are excluded", errInvalidFields)
+	}
+	return fields, nil
+}
+
+// splitFields splits a comma separated list of fields, ignoring empty entries.
+func splitFields(s string) []string {
+	fields := []string{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Has returns true if field is included.
+func (f fieldSet) Has(field string) bool {
+	return f == nil || f[field]
+}
+
+// Select restricts dbtx to the columns needed for the included fields and the columns of keyset,
+// which are needed to compute the cursors of the page.
+func (f fieldSet) Select(dbtx db.TX, keyset db.Keyset) db.TX {
+	if f == nil {
+		return dbtx
+	}
+	columns := f.columns(keyset)
+	args := make([]any, 0, len(columns)-1)
+	for _, column := range columns[1:] {
+		args = append(args, column)
+	}
+	return dbtx.Select(columns[0], args...)
+}
+
+// columns returns the sorted columns needed for the included fields and the columns of keyset.
+func (f fieldSet) columns(keyset db.Keyset) []string {
+	selected := map[string]bool{"id": true}
+	for field := range f {
+		for _, column := range articleFieldColumns[field] {
+			selected[column] = true
+		}
+	}
+	for _, field := range keyset {
+		selected[field.Column] = true
+	}
+
+	columns := make([]string, 0, len(selected))
+	for column := range selected {
+		columns = append(columns, column)
+	}
+	// a stable column order keeps the statement the same for equal field sets
+	sort.Strings(columns)
+	return columns
+}
+
+// Project returns article with the fields that are not included removed.
+// The article is returned unchanged if all fields are included.
+func (f fieldSet) Project(article *Article) (any, error) {
+	if f == nil {
+		return article, nil
+	}
+	data, err := json.Marshal(article)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+	for field := range fields {
+		if !f[field] {
+			delete(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// ProjectList returns list with the fields of its articles that are not included removed.
+func (f fieldSet) ProjectList(list ArticleList) (any, error) {
+	if f == nil {
+		return list, nil
+	}
+	items := make([]any, 0, len(list.Items))
+	for _, article := range list.Items {
+		item, err := f.Project(article)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return projectedArticleList{
+		Items:      items,
+		NextCursor: list.NextCursor,
+		PrevCursor: list.PrevCursor,
+	}, nil
+}
+
+// projectedArticleList is an ArticleList whose articles contain only the included fields.
+type projectedArticleList struct {
+	Items      []any  `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
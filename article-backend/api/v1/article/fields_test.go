@@ -0,0 +1,58 @@
+package article
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+)
+
+func TestFieldSetColumns(t *testing.T) {
+	keyset := db.Keyset{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}}
+	tests := []struct {
+		name    string
+		query   url.Values
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "default list",
+			query: url.Values{},
+			want: []string{
+				"author_id", "co_author_ids", "created_at", "deleted_at", "description", "id", "publish_at", "published",
+				"published_at", "published_by", "slug", "tags", "title", "updated_at", "version",
+			},
+		},
+		{
+			name:  "derived field",
+			query: url.Values{"fields": {"title,word_count"}},
+			want:  []string{"content", "created_at", "id", "title"},
+		},
+		{
+			name:  "embedded author",
+			query: url.Values{"fields": {"author"}},
+			want:  []string{"author_id", "created_at", "id"},
+		},
+		{
+			name:    "unknown field",
+			query:   url.Values{"fields": {"title,body"}},
+			wantErr: errInvalidFields,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := parseFieldSet(tt.query, defaultListExclude...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseFieldSet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, fields.columns(keyset)); diff != "" {
+				t.Errorf("columns() = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package article
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/pubsub"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+// maxImportErrors is the maximum number of failed records listed in an import report.
+const maxImportErrors = 100
+
+var (
+	errImportTrashed = fmt.Errorf("%w: article is in the trash, restore it before importing it", db.ErrConflict)
+)
+
+// ImportReport summarizes an import.
+type ImportReport struct {
+	// Created is the number of created articles.
+	Created int `json:"created"`
+	// Updated is the number of updated articles.
+	Updated int `json:"updated"`
+	// Failed is the number of records that could not be imported.
+	Failed int `json:"failed"`
+	// Aborted describes why the upload could not be read to the end, if it could not.
+	// Records following the unreadable one have not been imported.
+	Aborted string `json:"aborted,omitempty"`
+	// Errors lists the first failed records.
+	Errors []ImportError `json:"errors"`
+}
+
+// ImportError describes why a record could not be imported.
+type ImportError struct {
+	// Record is the number of the record in the upload, starting at 1. The CSV header is not counted.
+	Record int `json:"record"`
+	// ID is the ID of the article, if the record has one.
+	ID *uuid.UUID `json:"id,omitempty"`
+	// Error describes the problem.
+	Error string `json:"error"`
+	// Details lists the invalid fields of the article.
+	Details []server.FieldError `json:"details,omitempty"`
+}
+
+// importArticles reads articles from an NDJSON or CSV upload, as produced by exportArticles.
+// Articles with an ID that already exists are updated, all others are created.
+// Records of articles in the trash fail, the article has to be restored first.
+// Every record is imported on its own and the records are processed while the upload is read,
+// so failed records do not affect the others and uploads do not have to fit into memory.
+func (t *articleRouter) importArticles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	var next func() (*Article, error)
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case contentTypeNDJSON:
+		dec := json.NewDecoder(r.Body)
+		next = func() (*Article, error) {
+			article := &Article{}
+			err := dec.Decode(article)
+			// a value of the wrong type has been read completely, so the next record can still be decoded
+			if typeErr := (&json.UnmarshalTypeError{}); errors.As(err, &typeErr) {
+				return nil, fmt.Errorf("%w: %v", errInvalidRecord, err)
+			}
+			return article, err
+		}
+	case contentTypeCSV:
+		cr := csv.NewReader(r.Body)
+		columns, err := cr.Read()
+		if err == nil {
+			err = checkCSVColumns(columns)
+		}
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+				Status:  http.StatusBadRequest,
+				Message: "invalid CSV header",
+				Error:   err.Error(),
+			})
+			return
+		}
+		// records with a wrong number of fields are reported by articleFromCSV
+		cr.FieldsPerRecord = -1
+		next = func() (*Article, error) {
+			record, err := cr.Read()
+			// the reader continues with the line following a malformed record
+			if parseErr := (&csv.ParseError{}); errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("%w: %v", errInvalidRecord, err)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return articleFromCSV(columns, record)
+		}
+	default:
+		utils.WriteJSON(w, http.StatusUnsupportedMediaType, server.Error{
+			Status:  http.StatusUnsupportedMediaType,
+			Message: "unsupported import format",
+			Error:   "expected content type " + contentTypeNDJSON + " or " + contentTypeCSV,
+		})
+		return
+	}
+
+	// an import may take longer than the timeouts of the server
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	report := ImportReport{
+		Errors: []ImportError{},
+	}
+	for record := 1; ; record++ {
+		article, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, errInvalidRecord) {
+			report.Aborted = err.Error()
+			break
+		}
+		if err == nil {
+			var action pubsub.ActionType
+			action, err = t.upsertArticle(ctx, article)
+			if err == nil {
+				t.articleChanged(action, article)
+				if action == pubsub.ActionTypeCreate {
+					report.Created++
+				} else {
+					report.Updated++
+				}
+				continue
+			}
+		}
+
+		report.Failed++
+		if len(report.Errors) < maxImportErrors {
+			report.Errors = append(report.Errors, importError(record, article, err))
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, report)
+}
+
+// upsertArticle replaces the article with the ID of article or creates it, if it does not exist.
+// The returned action tells which of both happened.
+// Articles in the trash are neither replaced nor created again, as their ID is still taken.
+func (t *articleRouter) upsertArticle(ctx context.Context, article *Article) (pubsub.ActionType, error) {
+	err := article.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	if article.ID != uuid.Nil {
+		updated, err := t.replaceArticle(ctx, article.ID.String(), "", func(db.Repository, *Article) (*Article, error) {
+			return article, nil
+		})
+		if err == nil {
+			*article = *updated
+			return pubsub.ActionTypeUpdate, nil
+		}
+		if !errors.Is(err, errArticleNotFound) {
+			return "", err
+		}
+
+		trashed := int64(0)
+		err = t.db.Count(&Article{}, &trashed).Unscoped().Where("id = ?", article.ID).Where("deleted_at IS NOT NULL").Commit(ctx)
+		if err != nil {
+			return "", err
+		}
+		if trashed > 0 {
+			return "", errImportTrashed
+		}
+	}
+	return pubsub.ActionTypeCreate, t.insertArticle(ctx, article)
+}
+
+// importError describes a failed record.
+func importError(record int, article *Article, err error) ImportError {
+	ie := ImportError{
+		Record: record,
+		Error:  err.Error(),
+	}
+	if article != nil && article.ID != uuid.Nil {
+		id := article.ID
+		ie.ID = &id
+	}
+	var verrs server.ValidationErrors
+	if errors.As(err, &verrs) {
+		ie.Details = verrs
+	}
+	return ie
+}
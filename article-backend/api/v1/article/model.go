@@ -1,35 +1,352 @@
 package article
 
 import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/diff"
+	"github.com/leonsteinhaeuser/example-app/internal/markdown"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/slug"
+	"gorm.io/gorm"
 )
 
 type Article struct {
-	ID        uuid.UUID  `json:"id,omitempty" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	CreatedAt time.Time  `json:"created_at,omitempty"`
-	UpdatedAt time.Time  `json:"updated_at,omitempty"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	ID        uuid.UUID `json:"id,omitempty" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	// DeletedAt is the time the article was moved to the trash.
+	// Deleted articles are excluded from all queries, except for the trash.
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	// Version is incremented on every change of the article. It is used as ETag.
+	Version int64 `json:"version" gorm:"not null;default:1"`
 
 	// Title is the title of the article.
 	Title string `json:"title,omitempty"`
+	// Slug identifies the article in human-readable URLs. It is generated from the title of new articles,
+	// unless set by the client. Articles keep their slug when updated without one.
+	Slug string `json:"slug" gorm:"uniqueIndex:idx_articles_slug"`
 	// Description is the description of the article.
 	Description string `json:"description,omitempty"`
-	// Content is the content of the article.
+	// Content is the content of the article in Markdown.
 	Content string `json:"content,omitempty"`
+	// Excerpt is a plain text summary of the content. It is generated from its first paragraph.
+	Excerpt string `json:"excerpt" gorm:"-"`
+	// WordCount is the number of words of the content.
+	WordCount int `json:"word_count" gorm:"-"`
+	// ReadingTime is the estimated time it takes to read the content, in minutes.
+	ReadingTime int `json:"reading_time" gorm:"-"`
+	// ContentHTML is the content rendered to sanitized HTML. It is only set if requested with render=html.
+	ContentHTML string `json:"content_html,omitempty" gorm:"-"`
 	// Published is a flag indicating whether the article is published or not.
 	Published bool `json:"published"`
 	// PublishedAt is the time the article was published.
 	PublishedAt *time.Time `json:"published_at,omitempty"`
 	// PublishedBy is the ID of the user who published the article.
 	PublishedBy *uuid.UUID `json:"published_by,omitempty"`
+	// PublishAt is the time the article is scheduled to be published at.
+	PublishAt *time.Time `json:"publish_at,omitempty" gorm:"index"`
 
-	// Tags is a list of tags of the article.
-	Tags []string `json:"tags,omitempty" gorm:"serializer:json"`
+	// Tags is a list of tags of the article. Tags are stored trimmed, lower-cased and without duplicates.
+	Tags []string `json:"tags,omitempty" gorm:"type:jsonb;serializer:json;index:idx_articles_tags,type:gin"`
 
 	// AuthorID is the ID of the head author of the article.
-	AuthorID uuid.UUID `json:"author_id,omitempty"`
+	AuthorID uuid.UUID `json:"author_id,omitempty" gorm:"index"`
 	// CoAuthorIDs is a list of IDs of co-authors of the article.
-	CoAuthorIDs []uuid.UUID `json:"co_author_ids,omitempty" gorm:"serializer:json"`
+	CoAuthorIDs []uuid.UUID `json:"co_author_ids,omitempty" gorm:"type:jsonb;serializer:json;index:idx_articles_co_author_ids,type:gin"`
+
+	// Author is the profile of the head author. It is only set if requested with expand=author.
+	Author *Author `json:"author,omitempty" gorm:"-"`
+	// CoAuthors are the profiles of the co-authors. They are only set if requested with expand=co_authors.
+	CoAuthors []*Author `json:"co_authors,omitempty" gorm:"-"`
+}
+
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 1000
+	maxTagLength         = 50
+	maxSlugLength        = 100
+	maxCommentLength     = 5000
+	maxAuthorNameLength  = 200
+	maxAuthorBioLength   = 2000
+
+	// maxExcerptLength is the maximum number of characters of a generated excerpt.
+	maxExcerptLength = 280
+	// wordsPerMinute is the reading speed the reading time is estimated with.
+	wordsPerMinute = 200
+)
+
+// AfterFind computes the read-only fields of articles read from the database.
+func (a *Article) AfterFind(*gorm.DB) error {
+	a.computeFields()
+	return nil
+}
+
+// AfterSave computes the read-only fields of created and updated articles,
+// so that values sent by clients are replaced.
+func (a *Article) AfterSave(*gorm.DB) error {
+	a.computeFields()
+	// the profiles are only embedded on request, they are not part of the article
+	a.Author = nil
+	a.CoAuthors = nil
+	return nil
+}
+
+// computeFields computes the fields derived from the content.
+func (a *Article) computeFields() {
+	doc := markdown.Parse(a.Content)
+	a.Excerpt = doc.Excerpt(maxExcerptLength)
+	a.WordCount = markdown.WordCount(doc.Text())
+	// every started minute counts
+	a.ReadingTime = (a.WordCount + wordsPerMinute - 1) / wordsPerMinute
+}
+
+// Validate checks the fields clients may write. It returns server.ValidationErrors listing all invalid fields.
+func (a *Article) Validate() error {
+	v := server.Validation{}
+	v.Required("title", a.Title)
+	v.MaxLength("title", a.Title, maxTitleLength)
+	v.MaxLength("description", a.Description, maxDescriptionLength)
+	// an empty slug is generated or kept
+	v.Check(a.Slug == "" || slug.Valid(a.Slug), "slug", "must consist of lower-case letters and digits separated by single hyphens")
+	v.MaxLength("slug", a.Slug, maxSlugLength)
+	v.Check(a.AuthorID != uuid.Nil, "author_id", "must be set")
+
+	seen := map[uuid.UUID]bool{}
+	for i, id := range a.CoAuthorIDs {
+		field := fmt.Sprintf("co_author_ids[%d]", i)
+		v.Check(id != uuid.Nil, field, "must be set")
+		v.Check(id == uuid.Nil || id != a.AuthorID, field, "must not be the author")
+		v.Check(!seen[id], field, "must be unique")
+		seen[id] = true
+	}
+	for i, tag := range a.Tags {
+		// tags are normalized before they are stored, so only the normalized tag has to be valid
+		tag = normalizeTag(tag)
+		field := fmt.Sprintf("tags[%d]", i)
+		v.Required(field, tag)
+		v.MaxLength(field, tag, maxTagLength)
+	}
+	return v.Err()
+}
+
+// ArticleList is a page of articles.
+type ArticleList struct {
+	// Items are the articles of the page.
+	Items []*Article `json:"items"`
+	// NextCursor is the cursor of the following page. It is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+	// PrevCursor is the cursor of the preceding page. It is empty on the first page.
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// ArticleSlug is a previous slug of an article. Requests for it are redirected to the current slug.
+type ArticleSlug struct {
+	// Slug is the previous slug. It is released if another article is given this slug.
+	Slug      string    `json:"slug" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+
+	// ArticleID is the ID of the article the slug belonged to.
+	ArticleID uuid.UUID `json:"article_id" gorm:"type:uuid;index"`
+}
+
+// ArticleRevision is an immutable snapshot of an article, taken after each change.
+type ArticleRevision struct {
+	ID        uuid.UUID `json:"id,omitempty" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+
+	// ArticleID is the ID of the article the revision belongs to.
+	ArticleID uuid.UUID `json:"article_id" gorm:"type:uuid;uniqueIndex:idx_article_revisions_number"`
+	// Revision is the number of the revision. The revision of a newly created article is 1.
+	Revision int `json:"revision" gorm:"uniqueIndex:idx_article_revisions_number"`
+	// EditorID is the ID of the user who made the change.
+	EditorID *uuid.UUID `json:"editor_id,omitempty" gorm:"type:uuid"`
+	// ChangedFields is a list of the fields changed compared to the previous revision.
+	ChangedFields []string `json:"changed_fields" gorm:"serializer:json"`
+	// Article is the state of the article after the change.
+	Article Article `json:"article" gorm:"serializer:json"`
+}
+
+// ArticleRevisionList is a list of article revisions.
+type ArticleRevisionList struct {
+	// Items are the revisions, newest first.
+	Items []*ArticleRevision `json:"items"`
+}
+
+// RevisionDiff describes the changes between two revisions of an article.
+type RevisionDiff struct {
+	// From is the revision the changes are relative to.
+	From int `json:"from"`
+	// To is the revision containing the changes.
+	To int `json:"to"`
+	// Fields are the changed fields.
+	Fields []FieldDiff `json:"fields"`
+}
+
+// FieldDiff describes the change of a single field.
+type FieldDiff struct {
+	// Field is the name of the field.
+	Field string `json:"field"`
+	// Old is the value of the field in the older revision.
+	Old any `json:"old"`
+	// New is the value of the field in the newer revision.
+	New any `json:"new"`
+	// Lines is the line based diff of text fields.
+	Lines []diff.Edit `json:"lines,omitempty"`
+}
+
+// CommentStatus is the moderation state of a comment.
+type CommentStatus string
+
+const (
+	// CommentStatusPending is the state of new and edited comments until they are moderated.
+	CommentStatusPending CommentStatus = "pending"
+	// CommentStatusApproved is the state of comments visible to readers.
+	CommentStatusApproved CommentStatus = "approved"
+	// CommentStatusRejected is the state of comments hidden by a moderator.
+	CommentStatusRejected CommentStatus = "rejected"
+)
+
+// Valid returns true if s is a known moderation state.
+func (s CommentStatus) Valid() bool {
+	switch s {
+	case CommentStatusPending, CommentStatusApproved, CommentStatusRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+// Comment is a comment of a reader on an article, or a reply to another comment.
+type Comment struct {
+	ID        uuid.UUID `json:"id,omitempty" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	// DeletedAt is the time the comment was deleted. Deleted comments are excluded from all queries.
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// ArticleID is the ID of the article the comment belongs to.
+	ArticleID uuid.UUID `json:"article_id" gorm:"type:uuid;index"`
+	// ParentID is the ID of the comment this comment replies to. It is empty for top-level comments.
+	ParentID *uuid.UUID `json:"parent_id,omitempty" gorm:"type:uuid;index"`
+	// AuthorID is the ID of the user who wrote the comment. Only the author may edit or delete it.
+	AuthorID uuid.UUID `json:"author_id" gorm:"type:uuid;index"`
+	// Content is the text of the comment.
+	Content string `json:"content"`
+
+	// Status is the moderation state of the comment. Only approved comments are visible to readers.
+	Status CommentStatus `json:"status" gorm:"not null;default:pending;index"`
+	// ModeratedBy is the ID of the user who last approved or rejected the comment.
+	ModeratedBy *uuid.UUID `json:"moderated_by,omitempty" gorm:"type:uuid"`
+	// ModeratedAt is the time the comment was last approved or rejected.
+	ModeratedAt *time.Time `json:"moderated_at,omitempty"`
+
+	// Replies are the visible replies to the comment, oldest first. They are only set in threads.
+	Replies []*Comment `json:"replies,omitempty" gorm:"-"`
+}
+
+// CommentRequest is the body of a request creating or editing a comment.
+type CommentRequest struct {
+	// Content is the text of the comment.
+	Content string `json:"content"`
+	// ParentID is the ID of the comment to reply to. It is ignored when editing a comment.
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+}
+
+// Validate validates the comment request.
+func (c *CommentRequest) Validate() error {
+	v := server.Validation{}
+	v.Required("content", strings.TrimSpace(c.Content))
+	v.MaxLength("content", c.Content, maxCommentLength)
+	v.Check(c.ParentID == nil || *c.ParentID != uuid.Nil, "parent_id", "must not be empty")
+	return v.Err()
+}
+
+// ModerationRequest is the body of a request moderating a comment.
+type ModerationRequest struct {
+	// Status is the new moderation state of the comment.
+	Status CommentStatus `json:"status"`
+}
+
+// Validate validates the moderation request.
+func (m *ModerationRequest) Validate() error {
+	v := server.Validation{}
+	v.Check(m.Status.Valid(), "status", "must be pending, approved or rejected")
+	return v.Err()
+}
+
+// CommentList is a list of comments.
+type CommentList struct {
+	// Items are the comments, oldest first. In threads, these are the top-level comments.
+	Items []*Comment `json:"items"`
+}
+
+// Author is the profile of a user writing articles.
+type Author struct {
+	// ID is the ID of the author. It is the ID of the user, so it may be set by the client.
+	ID        uuid.UUID `json:"id,omitempty" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+
+	// Name is the name the author is shown with.
+	Name string `json:"name"`
+	// Email is the contact address of the author.
+	Email string `json:"email,omitempty"`
+	// Bio is a short description of the author.
+	Bio string `json:"bio,omitempty"`
+	// AvatarURL is the URL of a picture of the author.
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+// Validate validates the author.
+func (a *Author) Validate() error {
+	v := server.Validation{}
+	v.Required("name", strings.TrimSpace(a.Name))
+	v.MaxLength("name", a.Name, maxAuthorNameLength)
+	v.MaxLength("bio", a.Bio, maxAuthorBioLength)
+	if a.Email != "" {
+		address, err := mail.ParseAddress(a.Email)
+		v.Check(err == nil && address.Address == a.Email, "email", "must be an email address")
+	}
+	if a.AvatarURL != "" {
+		u, err := url.Parse(a.AvatarURL)
+		v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "avatar_url", "must be an absolute http or https URL")
+	}
+	return v.Err()
+}
+
+// AuthorList is a page of authors.
+type AuthorList struct {
+	// Items are the authors, ordered by name.
+	Items []*Author `json:"items"`
+}
+
+// Attachment is a file uploaded to an article, e.g. an image used in its content.
+// The content is kept in the blob store, the attachment describes it.
+type Attachment struct {
+	ID        uuid.UUID `json:"id,omitempty" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+
+	// ArticleID is the ID of the article the attachment belongs to.
+	ArticleID uuid.UUID `json:"article_id" gorm:"type:uuid;index"`
+	// UploaderID is the ID of the user who uploaded the attachment.
+	UploaderID *uuid.UUID `json:"uploader_id,omitempty" gorm:"type:uuid"`
+	// Filename is the name of the uploaded file, without directories.
+	Filename string `json:"filename"`
+	// ContentType is the media type detected from the content of the file.
+	ContentType string `json:"content_type"`
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+	// SHA256 is the hex encoded SHA-256 checksum of the file.
+	SHA256 string `json:"sha256"`
+}
+
+// AttachmentList is a list of attachments.
+type AttachmentList struct {
+	// Items are the attachments, oldest first.
+	Items []*Attachment `json:"items"`
 }
@@ -0,0 +1,86 @@
+package article
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+)
+
+func TestArticleValidate(t *testing.T) {
+	authorID := uuid.New()
+	coAuthorID := uuid.New()
+	valid := func(change func(a *Article)) *Article {
+		a := &Article{Title: "title", AuthorID: authorID}
+		change(a)
+		return a
+	}
+	tests := []struct {
+		name    string
+		article *Article
+		// want are the invalid fields.
+		want []string
+	}{
+		{
+			name:    "valid",
+			article: valid(func(a *Article) {}),
+		},
+		{
+			name: "all fields",
+			article: valid(func(a *Article) {
+				a.Slug = "my-title-2"
+				a.Description = "description"
+				a.Tags = []string{" Go ", "web"}
+				a.CoAuthorIDs = []uuid.UUID{coAuthorID}
+			}),
+		},
+		{
+			name:    "missing fields",
+			article: &Article{Title: " "},
+			want:    []string{"title", "author_id"},
+		},
+		{
+			name: "too long fields",
+			article: valid(func(a *Article) {
+				a.Title = strings.Repeat("a", maxTitleLength+1)
+				a.Description = strings.Repeat("a", maxDescriptionLength+1)
+				a.Slug = strings.Repeat("a", maxSlugLength+1)
+			}),
+			want: []string{"title", "description", "slug"},
+		},
+		{
+			name:    "invalid slug",
+			article: valid(func(a *Article) { a.Slug = "My Title" }),
+			want:    []string{"slug"},
+		},
+		{
+			name:    "invalid co-authors",
+			article: valid(func(a *Article) { a.CoAuthorIDs = []uuid.UUID{coAuthorID, uuid.Nil, authorID, coAuthorID} }),
+			want:    []string{"co_author_ids[1]", "co_author_ids[2]", "co_author_ids[3]"},
+		},
+		{
+			name:    "invalid tags",
+			article: valid(func(a *Article) { a.Tags = []string{"go", "  ", strings.Repeat("a", maxTagLength+1)} }),
+			want:    []string{"tags[1]", "tags[2]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.article.Validate()
+			var verrs server.ValidationErrors
+			if err != nil && !errors.As(err, &verrs) {
+				t.Fatalf("Validate() error = %v, want validation errors", err)
+			}
+			var got []string
+			for _, fe := range verrs {
+				got = append(got, fe.Field)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Validate() = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package article
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/jsonpatch"
+	"github.com/leonsteinhaeuser/example-app/internal/pubsub"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+// patchArticle applies a partial update to an article.
+// The request body must be either a JSON Merge Patch (application/merge-patch+json)
+// or a JSON Patch (application/json-patch+json) document. Unlike PUT, fields can be set to their
+// zero value, e.g. {"published": false, "tags": []}.
+func (t *articleRouter) patchArticle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	if t.ifMatchMissing(w, r) {
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch contentType {
+	case jsonpatch.ContentTypeMergePatch:
+		apply = jsonpatch.MergePatch
+	case jsonpatch.ContentTypeJSONPatch:
+		apply = jsonpatch.Apply
+	default:
+		utils.WriteJSON(w, http.StatusUnsupportedMediaType, server.Error{
+			Status:  http.StatusUnsupportedMediaType,
+			Message: "unsupported patch format",
+			Error:   "expected content type " + jsonpatch.ContentTypeMergePatch + " or " + jsonpatch.ContentTypeJSONPatch,
+		})
+		return
+	}
+
+	defer r.Body.Close()
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.log.Error(err).Log("failed to read request body")
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "failed to read request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	article, err := t.replaceArticle(ctx, id, r.Header.Get("If-Match"), func(_ db.Repository, current *Article) (*Article, error) {
+		doc, err := json.Marshal(current)
+		if err != nil {
+			return nil, err
+		}
+		patched, err := apply(doc, patch)
+		if err != nil {
+			return nil, err
+		}
+		article := &Article{}
+		err = json.Unmarshal(patched, article)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", jsonpatch.ErrInvalidPatch, err)
+		}
+		return article, article.Validate()
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, errPreconditionFailed):
+		utils.WriteJSON(w, http.StatusPreconditionFailed, server.Error{
+			Status:  http.StatusPreconditionFailed,
+			Message: "failed to patch article",
+			Error:   err.Error(),
+		})
+		return
+	case errors.Is(err, jsonpatch.ErrInvalidPatch):
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid patch",
+			Error:   err.Error(),
+		})
+		return
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		utils.WriteJSON(w, http.StatusConflict, server.Error{
+			Status:  http.StatusConflict,
+			Message: "failed to patch article",
+			Error:   err.Error(),
+		})
+		return
+	case errors.As(err, &server.ValidationErrors{}):
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, server.NewValidationError("invalid article", err))
+		return
+	case errors.Is(err, jsonpatch.ErrPathNotFound):
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, server.Error{
+			Status:  http.StatusUnprocessableEntity,
+			Message: "failed to patch article",
+			Error:   err.Error(),
+		})
+		return
+	default:
+		t.writeError(w, "failed to patch article", err)
+		return
+	}
+
+	t.articleChanged(pubsub.ActionTypeUpdate, article)
+	w.Header().Set("ETag", articleETag(article))
+	utils.WriteJSON(w, http.StatusOK, article)
+}
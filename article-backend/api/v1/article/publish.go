@@ -0,0 +1,212 @@
+package article
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/pubsub"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/server/middleware"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+const (
+	// DefaultPublishInterval is the default interval in which scheduled articles are published.
+	DefaultPublishInterval = 30 * time.Second
+
+	// publishBatchSize is the maximum number of scheduled articles published per transaction.
+	publishBatchSize = 100
+)
+
+// PublishRequest is the optional body of a publish request.
+type PublishRequest struct {
+	// PublishAt schedules the article to be published at the given time.
+	// If it is empty or in the past, the article is published immediately.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+}
+
+// publishColumns are the columns written when the publication state of an article changes.
+var publishColumns = []any{"published_at", "published_by", "publish_at", "version"}
+
+// publishArticle publishes an article or schedules it to be published at publishAt.
+// Publishing an already published article has no effect.
+func (t *articleRouter) publishArticle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	req := PublishRequest{}
+	if r.ContentLength != 0 {
+		err := utils.ReadJSON(r, &req)
+		if err != nil {
+			t.log.Error(err).Log("failed to parse JSON body")
+			utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+				Status:  http.StatusBadRequest,
+				Message: "failed to parse JSON body",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	article, changed, err := t.setPublicationState(ctx, id, func(article *Article) {
+		if article.Published {
+			return
+		}
+		if userID, ok := middleware.UserIDFromContext(ctx); ok {
+			article.PublishedBy = &userID
+		}
+		now := time.Now()
+		if req.PublishAt != nil && req.PublishAt.After(now) {
+			article.PublishAt = req.PublishAt
+			return
+		}
+		article.Published = true
+		article.PublishedAt = &now
+		article.PublishAt = nil
+	})
+	if err != nil {
+		t.writeError(w, "failed to publish article", err)
+		return
+	}
+
+	switch {
+	case changed && article.Published:
+		t.articleChanged(pubsub.ActionTypePublish, article)
+	case changed:
+		// the publication has been scheduled
+		t.articleChanged(pubsub.ActionTypeUpdate, article)
+	}
+	utils.WriteJSON(w, http.StatusOK, article)
+}
+
+// unpublishArticle takes an article offline and cancels a scheduled publication.
+func (t *articleRouter) unpublishArticle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	article, changed, err := t.setPublicationState(ctx, id, func(article *Article) {
+		article.Published = false
+		article.PublishedAt = nil
+		article.PublishedBy = nil
+		article.PublishAt = nil
+	})
+	if err != nil {
+		t.writeError(w, "failed to unpublish article", err)
+		return
+	}
+
+	if changed {
+		t.articleChanged(pubsub.ActionTypeUnpublish, article)
+	}
+	utils.WriteJSON(w, http.StatusOK, article)
+}
+
+// setPublicationState applies change to the article with the given ID and stores the publication fields.
+// A revision is recorded if the article changed. changed reports whether the article changed.
+func (t *articleRouter) setPublicationState(ctx context.Context, id string, change func(*Article)) (article *Article, changed bool, err error) {
+	err = t.db.Transaction(ctx, func(tx db.Repository) error {
+		current := []*Article{}
+		err := tx.Find(&current).Where("id = ?", id).Limit(1).ForUpdate(false).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		if len(current) == 0 {
+			return errArticleNotFound
+		}
+
+		before := *current[0]
+		article = current[0]
+		change(article)
+		if len(changedFields(&before, article)) == 0 {
+			return nil
+		}
+		changed = true
+		article.Version++
+
+		err = tx.Update(article).Select("published", publishColumns...).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		return t.createRevision(ctx, tx, article, &before)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return article, changed, nil
+}
+
+// RunPublishScheduler publishes scheduled articles once their publish_at time has passed.
+// It checks for due articles every interval and returns when ctx is canceled.
+// Multiple replicas may run the scheduler at the same time, since due articles are locked while being published.
+func (t *articleRouter) RunPublishScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPublishInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				published, err := t.publishScheduled(ctx)
+				if err != nil {
+					t.log.Error(err).Log("failed to publish scheduled articles")
+					break
+				}
+				if published > 0 {
+					t.log.Info().Field("count", published).Log("published scheduled articles")
+				}
+				if published < publishBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// publishScheduled publishes a batch of due articles and returns the number of published articles.
+func (t *articleRouter) publishScheduled(ctx context.Context) (int, error) {
+	due := []*Article{}
+	err := t.db.Transaction(ctx, func(tx db.Repository) error {
+		// skip articles locked by other replicas, they are published there
+		err := tx.Find(&due).
+			Where("published = ?", false).
+			Where("publish_at <= ?", time.Now()).
+			Order("publish_at", false).
+			Limit(publishBatchSize).
+			ForUpdate(true).
+			Commit(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, article := range due {
+			before := *article
+			article.Published = true
+			article.PublishedAt = article.PublishAt
+			article.PublishAt = nil
+			article.Version++
+			err = tx.Update(article).Select("published", publishColumns...).Commit(ctx)
+			if err != nil {
+				return err
+			}
+			err = t.createRevision(ctx, tx, article, &before)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, article := range due {
+		t.articleChanged(pubsub.ActionTypePublish, article)
+	}
+	return len(due), nil
+}
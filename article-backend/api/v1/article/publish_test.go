@@ -0,0 +1,190 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/log"
+)
+
+// publicationState is the state of an article written by publishing and unpublishing it.
+type publicationState struct {
+	Version     int64
+	Published   bool
+	PublishedAt *time.Time
+	PublishAt   *time.Time
+}
+
+func articlePublicationState(article *Article) publicationState {
+	return publicationState{
+		Version:     article.Version,
+		Published:   article.Published,
+		PublishedAt: article.PublishedAt,
+		PublishAt:   article.PublishAt,
+	}
+}
+
+func TestPublishScheduled(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	future := time.Now().Add(time.Hour).Truncate(time.Second)
+	published := past.Add(-time.Hour)
+
+	due := &Article{ID: uuid.New(), Version: 1, Title: "due", Slug: "due", PublishAt: &past}
+	scheduled := &Article{ID: uuid.New(), Version: 1, Title: "scheduled", Slug: "scheduled", PublishAt: &future}
+	unscheduled := &Article{ID: uuid.New(), Version: 1, Title: "unscheduled", Slug: "unscheduled"}
+	online := &Article{ID: uuid.New(), Version: 1, Title: "online", Slug: "online", Published: true, PublishedAt: &published}
+
+	repo := newFakeRepository(due, scheduled, unscheduled, online)
+	rt := NewArticleRouter(log.NewZerologWithWriter(io.Discard), repo)
+
+	got, err := rt.publishScheduled(context.Background())
+	if err != nil {
+		t.Fatalf("publishScheduled() error = %v", err)
+	}
+	if got != 1 {
+		t.Errorf("publishScheduled() = %v, want 1", got)
+	}
+
+	want := map[string]publicationState{
+		"due":         {Version: 2, Published: true, PublishedAt: &past},
+		"scheduled":   articlePublicationState(scheduled),
+		"unscheduled": articlePublicationState(unscheduled),
+		"online":      articlePublicationState(online),
+	}
+	states := map[string]publicationState{}
+	for _, article := range fakeRecords[Article](repo) {
+		states[article.Title] = articlePublicationState(article)
+	}
+	if diff := cmp.Diff(want, states); diff != "" {
+		t.Errorf("articles = %v", diff)
+	}
+
+	revisions := fakeRecords[ArticleRevision](repo)
+	if len(revisions) != 1 || revisions[0].ArticleID != due.ID || !cmp.Equal(revisions[0].ChangedFields, []string{"publish_at", "published", "published_at"}) {
+		t.Errorf("revisions = %+v, want a revision of the published article", revisions)
+	}
+
+	// the published article is not due anymore
+	got, err = rt.publishScheduled(context.Background())
+	if err != nil || got != 0 {
+		t.Errorf("publishScheduled() = %v, %v, want 0, nil", got, err)
+	}
+}
+
+func TestPublishArticle(t *testing.T) {
+	future := time.Now().Add(time.Hour).Truncate(time.Second)
+	published := time.Now().Add(-time.Hour).Truncate(time.Second)
+	draft := &Article{ID: uuid.New(), Version: 1, Title: "draft", Slug: "draft"}
+	scheduled := &Article{ID: uuid.New(), Version: 1, Title: "scheduled", Slug: "scheduled", PublishAt: &future}
+	online := &Article{ID: uuid.New(), Version: 1, Title: "online", Slug: "online", Published: true, PublishedAt: &published}
+
+	tests := []struct {
+		name       string
+		article    *Article
+		action     string
+		body       string
+		wantStatus int
+		// want is the state of the article after the request.
+		want publicationState
+		// wantPublishedAt is true if the article is published by the request, which sets the publication time.
+		wantPublishedAt bool
+	}{
+		{
+			name:            "publish",
+			article:         draft,
+			action:          "publish",
+			wantStatus:      http.StatusOK,
+			want:            publicationState{Version: 2, Published: true},
+			wantPublishedAt: true,
+		},
+		{
+			name:       "schedule",
+			article:    draft,
+			action:     "publish",
+			body:       `{"publish_at": "` + future.Format(time.RFC3339) + `"}`,
+			wantStatus: http.StatusOK,
+			want:       publicationState{Version: 2, PublishAt: &future},
+		},
+		{
+			name:            "publish scheduled article",
+			article:         scheduled,
+			action:          "publish",
+			wantStatus:      http.StatusOK,
+			want:            publicationState{Version: 2, Published: true},
+			wantPublishedAt: true,
+		},
+		{
+			name:       "publish published article",
+			article:    online,
+			action:     "publish",
+			wantStatus: http.StatusOK,
+			want:       publicationState{Version: 1, Published: true, PublishedAt: &published},
+		},
+		{
+			name:       "unpublish",
+			article:    online,
+			action:     "unpublish",
+			wantStatus: http.StatusOK,
+			want:       publicationState{Version: 2},
+		},
+		{
+			name:       "unpublish scheduled article",
+			article:    scheduled,
+			action:     "unpublish",
+			wantStatus: http.StatusOK,
+			want:       publicationState{Version: 2},
+		},
+		{
+			name:       "unpublish draft",
+			article:    draft,
+			action:     "unpublish",
+			wantStatus: http.StatusOK,
+			want:       publicationState{Version: 1},
+		},
+		{
+			name:       "publish missing article",
+			article:    &Article{ID: uuid.New()},
+			action:     "publish",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(draft, scheduled, online)
+			r := httptest.NewRequest(http.MethodPost, "/articles/"+tt.article.ID.String()+"/"+tt.action, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := serve(repo, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			got := &Article{}
+			err := json.Unmarshal(w.Body.Bytes(), got)
+			if err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if tt.wantPublishedAt {
+				// the publication time is the time of the request
+				if got.PublishedAt == nil {
+					t.Errorf("published_at is not set")
+				}
+				got.PublishedAt = nil
+			}
+			if diff := cmp.Diff(tt.want, articlePublicationState(got)); diff != "" {
+				t.Errorf("article = %v", diff)
+			}
+		})
+	}
+}
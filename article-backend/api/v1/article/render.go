@@ -0,0 +1,97 @@
+package article
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leonsteinhaeuser/example-app/internal/markdown"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+var (
+	errInvalidRender = errors.New("invalid render format")
+)
+
+// renderRequested returns true if the content of articles should be rendered to HTML.
+// It returns an error for unknown values of the render query parameter.
+func renderRequested(query url.Values) (bool, error) {
+	switch render := query.Get("render"); render {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: unknown format %q, expected html", errInvalidRender, render)
+	}
+}
+
+// renderContent sets the rendered content of articles.
+func renderContent(articles ...*Article) error {
+	for _, article := range articles {
+		html, err := markdown.Parse(article.Content).HTML()
+		if err != nil {
+			return err
+		}
+		article.ContentHTML = html
+	}
+	return nil
+}
+
+// writeRendered renders the content of articles if requested by r.
+// It returns false if the request failed, in which case the error has been written to w.
+func (t *articleRouter) writeRendered(w http.ResponseWriter, r *http.Request, articles ...*Article) bool {
+	render, err := renderRequested(r.URL.Query())
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid render format",
+			Error:   err.Error(),
+		})
+		return false
+	}
+	if !render {
+		return true
+	}
+	err = renderContent(articles...)
+	if err != nil {
+		t.writeError(w, "failed to render articles", err)
+		return false
+	}
+	return true
+}
+
+// getRenderedArticle returns the content of an article rendered to sanitized HTML.
+func (t *articleRouter) getRenderedArticle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	article, err := t.findCachedArticle(ctx, id)
+	if err != nil {
+		t.writeError(w, "failed to get article", err)
+		return
+	}
+
+	// the rendered content changes with the article, so it shares its ETag
+	etag := articleETag(article)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if server.ETagMatches(r.Header.Get("If-None-Match"), etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	err = renderContent(article)
+	if err != nil {
+		t.writeError(w, "failed to render article", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, article.ContentHTML)
+}
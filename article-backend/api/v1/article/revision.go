@@ -0,0 +1,286 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/diff"
+	"github.com/leonsteinhaeuser/example-app/internal/pubsub"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/server/middleware"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+var (
+	errRevisionNotFound = fmt.Errorf("revision %w", db.ErrNotFound)
+
+	// revisionIgnoredFields are article fields that are maintained by the database or derived from
+	// other fields and therefore not considered a change of the article.
+	revisionIgnoredFields = map[string]bool{
+		"id":           true,
+		"created_at":   true,
+		"updated_at":   true,
+		"deleted_at":   true,
+		"version":      true,
+		"excerpt":      true,
+		"word_count":   true,
+		"reading_time": true,
+		"content_html": true,
+		"author":       true,
+		"co_authors":   true,
+	}
+
+	// revisionTextFields are article fields that are compared line by line.
+	revisionTextFields = map[string]bool{
+		"title":       true,
+		"description": true,
+		"content":     true,
+	}
+)
+
+// createRevision stores a revision of article in tx.
+// The changed fields are computed against the latest revision. If the article has no revision yet,
+// previous is used instead. previous may be nil for new articles.
+func (t *articleRouter) createRevision(ctx context.Context, tx db.Repository, article *Article, previous *Article) error {
+	latest := []*ArticleRevision{}
+	err := tx.Find(&latest).Where("article_id = ?", article.ID).Order("revision", true).Limit(1).Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	number := 1
+	if len(latest) > 0 {
+		number = latest[0].Revision + 1
+		previous = &latest[0].Article
+	}
+
+	revision := &ArticleRevision{
+		ArticleID:     article.ID,
+		Revision:      number,
+		ChangedFields: changedFields(previous, article),
+		Article:       *article,
+	}
+	if editorID, ok := middleware.UserIDFromContext(ctx); ok {
+		revision.EditorID = &editorID
+	}
+	return tx.Create(ctx, revision)
+}
+
+// findRevision returns the revision with the given number of an article.
+func findRevision(ctx context.Context, tx db.Repository, articleID string, number int) (*ArticleRevision, error) {
+	revisions := []*ArticleRevision{}
+	err := tx.Find(&revisions).Where("article_id = ?", articleID).Where("revision = ?", number).Limit(1).Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, errRevisionNotFound
+	}
+	return revisions[0], nil
+}
+
+// articleFields returns the JSON fields of an article that are tracked by revisions.
+// A nil article has no fields.
+func articleFields(article *Article) map[string]any {
+	fields := map[string]any{}
+	if article == nil {
+		return fields
+	}
+	// marshalling an article cannot fail
+	data, _ := json.Marshal(article)
+	json.Unmarshal(data, &fields)
+	for field := range revisionIgnoredFields {
+		delete(fields, field)
+	}
+	return fields
+}
+
+// changedFields returns the sorted names of the fields that differ between before and after.
+func changedFields(before, after *Article) []string {
+	return diffFields(articleFields(before), articleFields(after))
+}
+
+// diffFields returns the sorted names of the fields that differ between before and after.
+func diffFields(before, after map[string]any) []string {
+	changed := []string{}
+	for field, value := range after {
+		if !reflect.DeepEqual(before[field], value) {
+			changed = append(changed, field)
+		}
+	}
+	for field := range before {
+		if _, ok := after[field]; !ok {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// revisionNumber parses the revision URL parameter.
+func revisionNumber(r *http.Request) (int, error) {
+	return strconv.Atoi(chi.URLParam(r, "revision"))
+}
+
+func (t *articleRouter) getRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	// unknown and trashed articles are reported as missing rather than as articles without revisions
+	_, err := findArticle(ctx, t.db, id)
+	if err != nil {
+		t.writeError(w, "failed to list revisions", err)
+		return
+	}
+
+	revisions := []*ArticleRevision{}
+	err = t.db.Find(&revisions).Where("article_id = ?", id).Order("revision", true).Commit(ctx)
+	if err != nil {
+		t.writeError(w, "failed to list revisions", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, ArticleRevisionList{
+		Items: revisions,
+	})
+}
+
+func (t *articleRouter) getRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	number, err := revisionNumber(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid revision",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	revision, err := findRevision(ctx, t.db, id, number)
+	if err != nil {
+		t.writeError(w, "failed to get revision", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, revision)
+}
+
+// diffRevision returns the changes of a revision.
+// Optional query parameters:
+// - from: int, the revision to compare against (default: the preceding revision)
+func (t *articleRouter) diffRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	number, err := revisionNumber(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid revision",
+			Error:   err.Error(),
+		})
+		return
+	}
+	from := number - 1
+	if f := r.URL.Query().Get("from"); f != "" {
+		from, err = strconv.Atoi(f)
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+				Status:  http.StatusBadRequest,
+				Message: "invalid revision",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	to, err := findRevision(ctx, t.db, id, number)
+	// revision 0 is the empty article before its creation
+	var old *ArticleRevision
+	if err == nil && from > 0 {
+		old, err = findRevision(ctx, t.db, id, from)
+	}
+	if err != nil {
+		t.writeError(w, "failed to get revision", err)
+		return
+	}
+
+	var oldArticle *Article
+	if old != nil {
+		oldArticle = &old.Article
+	}
+	oldFields, newFields := articleFields(oldArticle), articleFields(&to.Article)
+
+	result := RevisionDiff{
+		From:   from,
+		To:     number,
+		Fields: []FieldDiff{},
+	}
+	for _, field := range diffFields(oldFields, newFields) {
+		fd := FieldDiff{
+			Field: field,
+			Old:   oldFields[field],
+			New:   newFields[field],
+		}
+		if revisionTextFields[field] {
+			oldText, _ := fd.Old.(string)
+			newText, _ := fd.New.(string)
+			fd.Lines = diff.Lines(oldText, newText)
+		}
+		result.Fields = append(result.Fields, fd)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, result)
+}
+
+// restoreRevision overwrites an article with the state of one of its revisions.
+// The restore is recorded as a new revision.
+func (t *articleRouter) restoreRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	number, err := revisionNumber(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid revision",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	restored, err := t.replaceArticle(ctx, id, r.Header.Get("If-Match"), func(tx db.Repository, _ *Article) (*Article, error) {
+		revision, err := findRevision(ctx, tx, id, number)
+		if err != nil {
+			return nil, err
+		}
+		return &revision.Article, nil
+	})
+	if errors.Is(err, errPreconditionFailed) {
+		utils.WriteJSON(w, http.StatusPreconditionFailed, server.Error{
+			Status:  http.StatusPreconditionFailed,
+			Message: "failed to restore revision",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		t.writeError(w, "failed to restore revision", err)
+		return
+	}
+
+	t.articleChanged(pubsub.ActionTypeUpdate, restored)
+	w.Header().Set("ETag", articleETag(restored))
+	utils.WriteJSON(w, http.StatusOK, restored)
+}
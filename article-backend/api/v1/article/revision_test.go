@@ -0,0 +1,242 @@
+package article
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/diff"
+)
+
+func TestChangedFields(t *testing.T) {
+	authorID := uuid.New()
+	article := &Article{ID: uuid.New(), Version: 1, Title: "title", Content: "content", AuthorID: authorID, Tags: []string{"go"}}
+	tests := []struct {
+		name   string
+		before *Article
+		after  *Article
+		want   []string
+	}{
+		{
+			name:  "new article",
+			after: article,
+			want:  []string{"author_id", "content", "published", "slug", "tags", "title"},
+		},
+		{
+			name:   "unchanged",
+			before: article,
+			after:  article,
+			want:   []string{},
+		},
+		{
+			name:   "maintained and derived fields",
+			before: article,
+			after: &Article{
+				ID:          uuid.New(),
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+				Version:     2,
+				Title:       "title",
+				Content:     "content",
+				AuthorID:    authorID,
+				Tags:        []string{"go"},
+				Excerpt:     "excerpt",
+				WordCount:   1,
+				ReadingTime: 1,
+				ContentHTML: "<p>content</p>",
+				Author:      &Author{ID: authorID},
+			},
+			want: []string{},
+		},
+		{
+			name:   "changed and removed fields",
+			before: article,
+			after:  &Article{ID: article.ID, Version: 2, Title: "changed", Content: "content", AuthorID: authorID, Published: true},
+			want:   []string{"published", "tags", "title"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, changedFields(tt.before, tt.after)); diff != "" {
+				t.Errorf("changedFields() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestDiffRevision(t *testing.T) {
+	articleID := uuid.New()
+	revision := func(number int, title, content string) *ArticleRevision {
+		return &ArticleRevision{
+			ID:        uuid.New(),
+			ArticleID: articleID,
+			Revision:  number,
+			Article:   Article{ID: articleID, Version: int64(number), Title: title, Slug: "title", Content: content},
+		}
+	}
+	repo := newFakeRepository(
+		&Article{ID: articleID, Version: 3, Title: "title", Slug: "title", Content: "first\nthird\n"},
+		revision(1, "title", "first\nsecond\n"),
+		revision(2, "changed", "first\nsecond\n"),
+		revision(3, "title", "first\nthird\n"),
+	)
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		want       *RevisionDiff
+	}{
+		{
+			name:       "preceding revision",
+			path:       "/3/diff",
+			wantStatus: http.StatusOK,
+			want: &RevisionDiff{From: 2, To: 3, Fields: []FieldDiff{
+				{
+					Field: "content",
+					Old:   "first\nsecond\n",
+					New:   "first\nthird\n",
+					Lines: []diff.Edit{
+						{Operation: diff.OperationEqual, Text: "first"},
+						{Operation: diff.OperationDelete, Text: "second"},
+						{Operation: diff.OperationInsert, Text: "third"},
+					},
+				},
+				{
+					Field: "title",
+					Old:   "changed",
+					New:   "title",
+					Lines: []diff.Edit{
+						{Operation: diff.OperationDelete, Text: "changed"},
+						{Operation: diff.OperationInsert, Text: "title"},
+					},
+				},
+			}},
+		},
+		{
+			name:       "from an older revision",
+			path:       "/3/diff?from=1",
+			wantStatus: http.StatusOK,
+			want: &RevisionDiff{From: 1, To: 3, Fields: []FieldDiff{
+				{
+					Field: "content",
+					Old:   "first\nsecond\n",
+					New:   "first\nthird\n",
+					Lines: []diff.Edit{
+						{Operation: diff.OperationEqual, Text: "first"},
+						{Operation: diff.OperationDelete, Text: "second"},
+						{Operation: diff.OperationInsert, Text: "third"},
+					},
+				},
+			}},
+		},
+		{
+			name:       "unknown revision",
+			path:       "/4/diff",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid revision",
+			path:       "/latest/diff",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(repo, httptest.NewRequest(http.MethodGet, "/articles/"+articleID.String()+"/revisions"+tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.want == nil {
+				return
+			}
+
+			got := &RevisionDiff{}
+			err := json.Unmarshal(w.Body.Bytes(), got)
+			if err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff = %v", diff)
+			}
+		})
+	}
+}
+
+func TestRestoreRevision(t *testing.T) {
+	author := &Author{ID: uuid.New(), Name: "author"}
+	articleID := uuid.New()
+	revision := func(number int, title string) *ArticleRevision {
+		return &ArticleRevision{
+			ID:        uuid.New(),
+			ArticleID: articleID,
+			Revision:  number,
+			Article:   Article{ID: articleID, Version: int64(number), Title: title, Slug: "title", AuthorID: author.ID},
+		}
+	}
+	tests := []struct {
+		name       string
+		revision   string
+		ifMatch    string
+		wantStatus int
+		// wantTitle is the title of the article after the request.
+		wantTitle string
+		// wantRevisions are the titles of the revisions after the request.
+		wantRevisions []string
+	}{
+		{
+			name:          "restore",
+			revision:      "1",
+			wantStatus:    http.StatusOK,
+			wantTitle:     "first",
+			wantRevisions: []string{"first", "second", "first"},
+		},
+		{
+			name:          "modified article",
+			revision:      "1",
+			ifMatch:       `"1"`,
+			wantStatus:    http.StatusPreconditionFailed,
+			wantTitle:     "second",
+			wantRevisions: []string{"first", "second"},
+		},
+		{
+			name:          "unknown revision",
+			revision:      "3",
+			wantStatus:    http.StatusNotFound,
+			wantTitle:     "second",
+			wantRevisions: []string{"first", "second"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(
+				author,
+				&Article{ID: articleID, Version: 2, Title: "second", Slug: "title", AuthorID: author.ID},
+				revision(1, "first"),
+				revision(2, "second"),
+			)
+			r := httptest.NewRequest(http.MethodPost, "/articles/"+articleID.String()+"/revisions/"+tt.revision+"/restore", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := serve(repo, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := fakeRecords[Article](repo)[0].Title; got != tt.wantTitle {
+				t.Errorf("title = %q, want %q", got, tt.wantTitle)
+			}
+			revisions := []string{}
+			for _, revision := range fakeRecords[ArticleRevision](repo) {
+				revisions = append(revisions, revision.Article.Title)
+			}
+			if diff := cmp.Diff(tt.wantRevisions, revisions); diff != "" {
+				t.Errorf("revisions = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package article
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+const (
+	// DefaultSearchLanguage is the text search configuration used if none is configured.
+	DefaultSearchLanguage = "english"
+
+	// snippetStart and snippetStop enclose the matching terms in snippets as returned by the database.
+	// They are characters of the private use area, so they survive HTML escaping and do not occur in regular text.
+	snippetStart = "\ue000"
+	snippetStop  = "\ue001"
+
+	// searchHeadlineOptions configures the highlighted snippets of search results.
+	searchHeadlineOptions = `StartSel="` + snippetStart + `", StopSel="` + snippetStop + `", MaxWords=35, MinWords=15, MaxFragments=2`
+)
+
+var (
+	// searchLanguagePattern matches valid text search configuration names.
+	// The language is part of the generated column definition, so it must be checked before use.
+	searchLanguagePattern = regexp.MustCompile(`^[a-z_]+$`)
+)
+
+// SearchResult is an article matching a full-text search.
+type SearchResult struct {
+	Article
+	// Rank is the relevance of the article for the search query. Higher is better.
+	Rank float64 `json:"rank" gorm:"->"`
+	// Snippet is an HTML escaped excerpt of the article with the matching terms enclosed in <mark> tags.
+	Snippet string `json:"snippet" gorm:"->"`
+}
+
+// TableName tells gorm to read search results from the articles table.
+func (SearchResult) TableName() string {
+	return "articles"
+}
+
+// SearchResultList is a page of search results.
+type SearchResultList struct {
+	// Items are the search results of the page, ordered by rank.
+	Items []*SearchResult `json:"items"`
+}
+
+// highlightSnippet escapes the text of a snippet returned by the database
+// and replaces the markers of the matching terms with <mark> tags.
+func highlightSnippet(snippet string) string {
+	// the markers are removed from the text before the snippet is generated, so they cannot be injected
+	snippet = html.EscapeString(snippet)
+	snippet = strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>").Replace(snippet)
+	return snippet
+}
+
+// searchMigrations returns the statements maintaining the search_vector column and its index.
+// The column is recreated if it was generated for another language.
+func searchMigrations(language string) ([]string, error) {
+	if !searchLanguagePattern.MatchString(language) {
+		return nil, fmt.Errorf("invalid search language %q", language)
+	}
+	return []string{
+		fmt.Sprintf(`DO $$
+BEGIN
+	IF NOT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'articles' AND column_name = 'search_vector'
+		AND generation_expression LIKE '%%''%[1]s''::regconfig%%'
+	) THEN
+		ALTER TABLE articles DROP COLUMN IF EXISTS search_vector;
+		ALTER TABLE articles ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('%[1]s'::regconfig, coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('%[1]s'::regconfig, coalesce(description, '')), 'B') ||
+			setweight(to_tsvector('%[1]s'::regconfig, coalesce(content, '')), 'C')
+		) STORED;
+	END IF;
+END $$`, language),
+		`CREATE INDEX IF NOT EXISTS idx_articles_search_vector ON articles USING GIN (search_vector)`,
+	}, nil
+}
+
+// searchArticles runs a full-text search over the title, description and content of articles.
+// Required query parameters:
+// - q: search query in web search syntax, e.g. "kubernetes -helm" or "\"rolling update\""
+// Optional query parameters:
+// - limit: int (default: 50, max: 500)
+// - offset: int
+// - and all filters supported by getArticles
+func (t *articleRouter) searchArticles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	q := query.Get("q")
+	if q == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "missing search query",
+			Error:   "the query parameter q is required",
+		})
+		return
+	}
+
+	limit := defaultListLimit
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	offset := 0
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	results := []*SearchResult{}
+	dbtx := t.db.Find(&results).
+		Select("articles.*, ts_rank(search_vector, websearch_to_tsquery(?::regconfig, ?)) AS rank, "+
+			"ts_headline(?::regconfig, translate(concat_ws(' ', description, content), ?, ''), websearch_to_tsquery(?::regconfig, ?), ?) AS snippet",
+			t.searchLanguage, q, t.searchLanguage, snippetStart+snippetStop, t.searchLanguage, q, searchHeadlineOptions,
+		).
+		Where("search_vector @@ websearch_to_tsquery(?::regconfig, ?)", t.searchLanguage, q)
+	dbtx = filterArticles(dbtx, query)
+	err := dbtx.Order("rank", true).Order("id", false).Offset(offset).Limit(limit).Commit(ctx)
+	if err != nil {
+		t.writeError(w, "failed to search articles", err)
+		return
+	}
+	for _, result := range results {
+		result.Snippet = highlightSnippet(result.Snippet)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, SearchResultList{
+		Items: results,
+	})
+}
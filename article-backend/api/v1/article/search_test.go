@@ -0,0 +1,36 @@
+package article
+
+import (
+	"testing"
+)
+
+func TestHighlightSnippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{
+			name:    "plain text",
+			snippet: "rolling updates of deployments",
+			want:    "rolling updates of deployments",
+		},
+		{
+			name:    "highlighted terms",
+			snippet: "rolling " + snippetStart + "updates" + snippetStop + " of " + snippetStart + "deployments" + snippetStop,
+			want:    "rolling <mark>updates</mark> of <mark>deployments</mark>",
+		},
+		{
+			name:    "html in the text",
+			snippet: `<script>alert("x")</script> ` + snippetStart + "update" + snippetStop + " <mark>",
+			want:    `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; <mark>update</mark> &lt;mark&gt;`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightSnippet(tt.snippet); got != tt.want {
+				t.Errorf("highlightSnippet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package article
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/slug"
+)
+
+const (
+	// defaultSlug is the slug of articles whose title contains no characters usable in a slug.
+	defaultSlug = "article"
+	// slugIndex is the unique index on the slugs of articles.
+	slugIndex = "idx_articles_slug"
+	// maxSlugAttempts is the number of times an article is stored with a generated slug
+	// before a conflict with concurrently stored articles is returned.
+	maxSlugAttempts = 5
+)
+
+var (
+	errSlugConflict = fmt.Errorf("%w: slug is used by another article", db.ErrConflict)
+)
+
+// assignSlug sets the slug of article before it is stored.
+// previous is the stored state of the article, or nil if the article is new.
+// Articles without slug get one generated from their title, unless they already have one.
+// The replaced slug is added to the slug history, so that links using it keep working.
+func assignSlug(ctx context.Context, tx db.Repository, article *Article, previous *Article) error {
+	if article.Slug == "" && previous != nil {
+		article.Slug = previous.Slug
+	}
+	if previous != nil && article.Slug == previous.Slug && article.Slug != "" {
+		return nil
+	}
+
+	if article.Slug == "" {
+		generated, err := uniqueSlug(ctx, tx, article.Title)
+		if err != nil {
+			return err
+		}
+		article.Slug = generated
+	} else {
+		// deleted articles keep their slug, so that they can be restored
+		used := int64(0)
+		err := tx.Count(&Article{}, &used).Unscoped().Where("slug = ?", article.Slug).Where("id <> ?", article.ID).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		if used > 0 {
+			return fmt.Errorf("%w: %s", errSlugConflict, article.Slug)
+		}
+	}
+
+	// the slug is current again or taken over from another article, so it must not redirect anymore
+	err := tx.Delete(&ArticleSlug{}).Where("slug = ?", article.Slug).Commit(ctx)
+	if err != nil {
+		return err
+	}
+	if previous == nil || previous.Slug == "" {
+		return nil
+	}
+	return tx.Create(ctx, &ArticleSlug{
+		Slug:      previous.Slug,
+		ArticleID: previous.ID,
+	})
+}
+
+// storeWithSlug assigns the slug of article with assignSlug and stores the article with store.
+// A generated slug may be taken by a concurrent write between both steps. The unique index rejects
+// the second write, which is then retried with the next free slug. Slugs set by the client are not retried.
+func storeWithSlug(ctx context.Context, tx db.Repository, article *Article, previous *Article, store func(tx db.Repository) error) error {
+	generated := article.Slug == "" && (previous == nil || previous.Slug == "")
+	for attempt := 1; ; attempt++ {
+		// the savepoint keeps a failed attempt from aborting tx
+		err := tx.Transaction(ctx, func(tx db.Repository) error {
+			err := assignSlug(ctx, tx, article, previous)
+			if err != nil {
+				return err
+			}
+			return store(tx)
+		})
+		if !generated || attempt == maxSlugAttempts || db.ConflictConstraint(err) != slugIndex {
+			return err
+		}
+		article.Slug = ""
+	}
+}
+
+// uniqueSlug generates a slug from title that is neither the current nor a previous slug of any article.
+// If the slug of the title is taken, a number is appended.
+func uniqueSlug(ctx context.Context, tx db.Repository, title string) (string, error) {
+	base := slug.Make(title, maxSlugLength)
+	if base == "" {
+		base = defaultSlug
+	}
+
+	// slugs consist of letters, digits and hyphens, so the base cannot contain LIKE wildcards
+	taken := []string{}
+	err := tx.Query(ctx, &taken,
+		`SELECT slug FROM articles WHERE slug = @base OR slug LIKE @pattern
+		UNION SELECT slug FROM article_slugs WHERE slug = @base OR slug LIKE @pattern`,
+		map[string]any{"base": base, "pattern": base + "-%"},
+	)
+	if err != nil {
+		return "", err
+	}
+	used := make(map[string]bool, len(taken))
+	for _, s := range taken {
+		used[s] = true
+	}
+
+	candidate := base
+	for i := 2; used[candidate]; i++ {
+		suffix := fmt.Sprintf("-%d", i)
+		candidate = slug.Truncate(base, maxSlugLength-len(suffix)) + suffix
+	}
+	return candidate, nil
+}
+
+// backfillSlugs generates the slugs of articles created before articles had slugs.
+// The version of every changed article is incremented, so that ETags and cached copies of it become stale.
+func (t *articleRouter) backfillSlugs(ctx context.Context) error {
+	missing := []*Article{}
+	err := t.db.Find(&missing).Unscoped().Select("id", "title", "version").Where("slug IS NULL OR slug = ''").Commit(ctx)
+	if err != nil {
+		return err
+	}
+	for _, article := range missing {
+		err = t.db.Transaction(ctx, func(tx db.Repository) error {
+			generated, err := uniqueSlug(ctx, tx, article.Title)
+			if err != nil {
+				return err
+			}
+			article.Slug = generated
+			article.Version++
+			return tx.Update(article).Unscoped().Select("slug", "version").Commit(ctx)
+		})
+		if err != nil {
+			return err
+		}
+		t.invalidateCache(ctx, article.ID)
+	}
+	return nil
+}
+
+// getArticleBySlug returns the article with the given slug.
+// Requests for a previous slug of an article are redirected to its current slug.
+func (t *articleRouter) getArticleBySlug(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	s := chi.URLParam(r, "slug")
+
+	articles := []*Article{}
+	err := t.db.Find(&articles).Where("slug = ?", s).Limit(1).Commit(ctx)
+	if err != nil {
+		t.writeError(w, "failed to get article", err)
+		return
+	}
+	if len(articles) > 0 {
+		t.writeArticle(w, r, articles[0])
+		return
+	}
+
+	current := []string{}
+	err = t.db.Query(ctx, &current,
+		`SELECT articles.slug FROM article_slugs
+		JOIN articles ON articles.id = article_slugs.article_id
+		WHERE article_slugs.slug = ? AND articles.deleted_at IS NULL`,
+		s,
+	)
+	if err != nil {
+		t.writeError(w, "failed to get article", err)
+		return
+	}
+	if len(current) == 0 {
+		t.writeError(w, "failed to get article", errArticleNotFound)
+		return
+	}
+
+	// the location is relative to the requested URL, so it works wherever the router is mounted
+	location := url.URL{
+		Path:     current[0],
+		RawQuery: r.URL.RawQuery,
+	}
+	http.Redirect(w, r, location.String(), http.StatusMovedPermanently)
+}
@@ -0,0 +1,291 @@
+package article
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/log"
+)
+
+// fakeSlugQuery answers the queries of uniqueSlug and getArticleBySlug with the articles and slugs of repo.
+func fakeSlugQuery(repo *fakeRepository) func(dest any, sql string, args ...any) error {
+	return func(dest any, sql string, args ...any) error {
+		found := dest.(*[]string)
+		if strings.Contains(sql, "JOIN") {
+			// the current slug of the article that had the requested slug
+			for _, previous := range fakeRecords[ArticleSlug](repo) {
+				if previous.Slug != args[0] {
+					continue
+				}
+				for _, article := range fakeRecords[Article](repo) {
+					if article.ID == previous.ArticleID && !article.DeletedAt.Valid {
+						*found = append(*found, article.Slug)
+					}
+				}
+			}
+			return nil
+		}
+
+		// the slugs that are equal to the base or start with it followed by a hyphen
+		base := args[0].(map[string]any)["base"].(string)
+		taken := func(s string) bool {
+			return s == base || strings.HasPrefix(s, base+"-")
+		}
+		for _, article := range fakeRecords[Article](repo) {
+			if taken(article.Slug) {
+				*found = append(*found, article.Slug)
+			}
+		}
+		for _, previous := range fakeRecords[ArticleSlug](repo) {
+			if taken(previous.Slug) {
+				*found = append(*found, previous.Slug)
+			}
+		}
+		return nil
+	}
+}
+
+func TestUniqueSlug(t *testing.T) {
+	articleID := uuid.New()
+	tests := []struct {
+		name    string
+		title   string
+		records []any
+		want    string
+	}{
+		{
+			name:  "free slug",
+			title: "Hello, World!",
+			want:  "hello-world",
+		},
+		{
+			name:    "taken slug",
+			title:   "Hello World",
+			records: []any{&Article{ID: articleID, Version: 1, Title: "hello", Slug: "hello-world"}},
+			want:    "hello-world-2",
+		},
+		{
+			name:  "previous slug",
+			title: "Hello World",
+			records: []any{
+				&Article{ID: articleID, Version: 1, Title: "hello", Slug: "hello-world-2"},
+				&ArticleSlug{Slug: "hello-world", ArticleID: articleID},
+			},
+			want: "hello-world-3",
+		},
+		{
+			name:    "slug with the same prefix",
+			title:   "Hello",
+			records: []any{&Article{ID: articleID, Version: 1, Title: "hello world", Slug: "hello-world"}},
+			want:    "hello",
+		},
+		{
+			name:  "title without letters",
+			title: "!?",
+			want:  defaultSlug,
+		},
+		{
+			name:    "truncated slug",
+			title:   strings.Repeat("a", maxSlugLength),
+			records: []any{&Article{ID: articleID, Version: 1, Title: "long", Slug: strings.Repeat("a", maxSlugLength)}},
+			want:    strings.Repeat("a", maxSlugLength-2) + "-2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(tt.records...)
+			repo.query = fakeSlugQuery(repo)
+
+			got, err := uniqueSlug(context.Background(), repo, tt.title)
+			if err != nil {
+				t.Fatalf("uniqueSlug() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("uniqueSlug() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetArticleBySlug(t *testing.T) {
+	article := &Article{ID: uuid.New(), Version: 1, Title: "current", Slug: "current"}
+	trashed := trashedArticle("trashed", time.Now())
+	repo := newFakeRepository(
+		article,
+		trashed,
+		&ArticleSlug{Slug: "previous", ArticleID: article.ID},
+		&ArticleSlug{Slug: "previous-of-trashed", ArticleID: trashed.ID},
+	)
+	repo.query = fakeSlugQuery(repo)
+	tests := []struct {
+		name         string
+		path         string
+		wantStatus   int
+		wantLocation string
+	}{
+		{
+			name:       "current slug",
+			path:       "current",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:         "previous slug",
+			path:         "previous",
+			wantStatus:   http.StatusMovedPermanently,
+			wantLocation: "/articles/by-slug/current",
+		},
+		{
+			name:         "previous slug with query",
+			path:         "previous?fields=title",
+			wantStatus:   http.StatusMovedPermanently,
+			wantLocation: "/articles/by-slug/current?fields=title",
+		},
+		{
+			name:       "trashed article",
+			path:       "trashed",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "previous slug of trashed article",
+			path:       "previous-of-trashed",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unknown slug",
+			path:       "unknown",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(repo, httptest.NewRequest(http.MethodGet, "/articles/by-slug/"+tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("location = %q, want %q", got, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestSlugHistory(t *testing.T) {
+	author := &Author{ID: uuid.New(), Name: "author"}
+	article := &Article{ID: uuid.New(), Version: 1, Title: "title", Slug: "title", AuthorID: author.ID}
+	other := &Article{ID: uuid.New(), Version: 1, Title: "other", Slug: "other", AuthorID: author.ID}
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantSlug   string
+		// wantHistory are the previous slugs after the request.
+		wantHistory []string
+	}{
+		{
+			name:        "changed slug",
+			body:        `{"title": "title", "slug": "changed", "author_id": "` + author.ID.String() + `"}`,
+			wantStatus:  http.StatusNoContent,
+			wantSlug:    "changed",
+			wantHistory: []string{"taken-back", "title"},
+		},
+		{
+			name:        "kept slug",
+			body:        `{"title": "changed title", "author_id": "` + author.ID.String() + `"}`,
+			wantStatus:  http.StatusNoContent,
+			wantSlug:    "title",
+			wantHistory: []string{"taken-back"},
+		},
+		{
+			name:        "previous slug taken back",
+			body:        `{"title": "title", "slug": "taken-back", "author_id": "` + author.ID.String() + `"}`,
+			wantStatus:  http.StatusNoContent,
+			wantSlug:    "taken-back",
+			wantHistory: []string{"title"},
+		},
+		{
+			name:        "slug of other article",
+			body:        `{"title": "title", "slug": "other", "author_id": "` + author.ID.String() + `"}`,
+			wantStatus:  http.StatusConflict,
+			wantSlug:    "title",
+			wantHistory: []string{"taken-back"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(author, article, other, &ArticleSlug{Slug: "taken-back", ArticleID: article.ID})
+			repo.query = fakeSlugQuery(repo)
+			r := httptest.NewRequest(http.MethodPut, "/articles/"+article.ID.String(), strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := serve(repo, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := fakeRecords[Article](repo)[0].Slug; got != tt.wantSlug {
+				t.Errorf("slug = %q, want %q", got, tt.wantSlug)
+			}
+			history := []string{}
+			for _, previous := range fakeRecords[ArticleSlug](repo) {
+				history = append(history, previous.Slug)
+			}
+			if diff := cmp.Diff(tt.wantHistory, history, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("slug history = %v", diff)
+			}
+		})
+	}
+}
+
+func TestBackfillSlugs(t *testing.T) {
+	withSlug := &Article{ID: uuid.New(), Version: 1, Title: "hello", Slug: "hello"}
+	missing := &Article{ID: uuid.New(), Version: 3, Title: "Hello"}
+	trashed := trashedArticle("", time.Now())
+	trashed.Title = "Trashed"
+	repo := newFakeRepository(withSlug, missing, trashed)
+	repo.query = fakeSlugQuery(repo)
+	cache := &memoryKeyStore{values: map[string][]byte{}}
+	rt := NewArticleRouter(log.NewZerologWithWriter(io.Discard), repo, WithCache(cache, time.Minute))
+
+	// the cached copy of the article must not outlive the backfill
+	_, err := rt.findCachedArticle(context.Background(), missing.ID.String())
+	if err != nil {
+		t.Fatalf("findCachedArticle() error = %v", err)
+	}
+
+	err = rt.backfillSlugs(context.Background())
+	if err != nil {
+		t.Fatalf("backfillSlugs() error = %v", err)
+	}
+
+	type slugState struct {
+		Slug    string
+		Version int64
+	}
+	want := map[string]slugState{
+		"hello":   {Slug: "hello", Version: 1},
+		"Hello":   {Slug: "hello-2", Version: 4},
+		"Trashed": {Slug: "trashed", Version: 2},
+	}
+	got := map[string]slugState{}
+	for _, article := range fakeRecords[Article](repo) {
+		got[article.Title] = slugState{Slug: article.Slug, Version: article.Version}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("articles = %v", diff)
+	}
+
+	cached, err := rt.findCachedArticle(context.Background(), missing.ID.String())
+	if err != nil {
+		t.Fatalf("findCachedArticle() error = %v", err)
+	}
+	if cached.Slug != "hello-2" || cached.Version != 4 {
+		t.Errorf("cached article has slug %q and version %v, want hello-2 and 4", cached.Slug, cached.Version)
+	}
+}
@@ -0,0 +1,224 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/pubsub"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+)
+
+var (
+	errInvalidTag = errors.New("tag must not be empty")
+)
+
+// Tag is a tag and the number of articles that have it.
+type Tag struct {
+	// Name is the normalized name of the tag.
+	Name string `json:"name"`
+	// Count is the number of articles with the tag.
+	Count int64 `json:"count"`
+}
+
+// TagList is a list of tags.
+type TagList struct {
+	// Items are the tags, most used first.
+	Items []*Tag `json:"items"`
+}
+
+// RenameTagRequest is the body of a tag rename request.
+type RenameTagRequest struct {
+	// Name is the new name of the tag. If another tag with this name exists, the tags are merged.
+	Name string `json:"name"`
+}
+
+// RenameTagResult is the response of a tag rename request.
+type RenameTagResult struct {
+	// From is the previous name of the tag.
+	From string `json:"from"`
+	// To is the new name of the tag.
+	To string `json:"to"`
+	// Articles is the number of changed articles.
+	Articles int `json:"articles"`
+}
+
+// normalizeTag trims and lower-cases a tag.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// normalizeTags normalizes tags and removes empty tags and duplicates.
+// The order of the first occurrence of each tag is kept.
+func normalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// jsonArray returns a JSON array of values, which is used as operand of jsonb containment queries.
+func jsonArray(values ...string) string {
+	// marshalling strings cannot fail
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// filterTags restricts dbtx to articles that have all or any of tags.
+func filterTags(dbtx db.TX, tags []string, all bool) db.TX {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return dbtx
+	}
+	if all {
+		return dbtx.Where("tags @> ?::jsonb", jsonArray(tags...))
+	}
+	conditions := make([]string, 0, len(tags))
+	args := make([]any, 0, len(tags))
+	for _, tag := range tags {
+		conditions = append(conditions, "tags @> ?::jsonb")
+		args = append(args, jsonArray(tag))
+	}
+	return dbtx.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+// getTags returns all tags of articles that are not deleted, together with the number of articles per tag.
+func (t *articleRouter) getTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tags := []*Tag{}
+	err := t.db.Query(ctx, &tags,
+		`SELECT tag AS name, count(*) AS count
+		FROM articles, jsonb_array_elements_text(CASE jsonb_typeof(tags) WHEN 'array' THEN tags ELSE '[]' END) AS tag
+		WHERE deleted_at IS NULL
+		GROUP BY tag
+		ORDER BY count DESC, tag`,
+	)
+	if err != nil {
+		t.writeError(w, "failed to list tags", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, TagList{
+		Items: tags,
+	})
+}
+
+// renameTag renames a tag on all articles, including deleted ones.
+// If an article already has a tag with the new name, both tags are merged.
+func (t *articleRouter) renameTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	from, err := tagParam(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid tag",
+			Error:   err.Error(),
+		})
+		return
+	}
+	from = normalizeTag(from)
+
+	req := RenameTagRequest{}
+	err = utils.ReadJSON(r, &req)
+	if err != nil {
+		t.log.Error(err).Log("failed to parse JSON body")
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "failed to parse JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+	to := normalizeTag(req.Name)
+	if from == "" || to == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, server.Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid tag",
+			Error:   errInvalidTag.Error(),
+		})
+		return
+	}
+
+	renamed, err := t.replaceTag(ctx, from, to)
+	if err != nil {
+		t.writeError(w, "failed to rename tag", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, RenameTagResult{
+		From:     from,
+		To:       to,
+		Articles: renamed,
+	})
+}
+
+// tagParam returns the tag in the URL of r. Tags may contain slashes, which are encoded in the URL.
+// chi matches the escaped path if it differs from the decoded path, so the parameter is still escaped then.
+func tagParam(r *http.Request) (string, error) {
+	tag := chi.URLParam(r, "tag")
+	if r.URL.RawPath == "" {
+		return tag, nil
+	}
+	return url.PathUnescape(tag)
+}
+
+// replaceTag replaces the tag from with to on all articles and returns the number of changed articles.
+// A revision is recorded for every changed article.
+func (t *articleRouter) replaceTag(ctx context.Context, from, to string) (int, error) {
+	if from == to {
+		return 0, nil
+	}
+	articles := []*Article{}
+	err := t.db.Transaction(ctx, func(tx db.Repository) error {
+		err := tx.Find(&articles).Unscoped().Where("tags @> ?::jsonb", jsonArray(from)).ForUpdate(false).Commit(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, article := range articles {
+			before := *article
+			tags := make([]string, len(article.Tags))
+			for i, tag := range article.Tags {
+				if tag == from {
+					tag = to
+				}
+				tags[i] = tag
+			}
+			article.Tags = normalizeTags(tags)
+			article.Version++
+			err = tx.Update(article).Unscoped().Select("tags", "version").Commit(ctx)
+			if err != nil {
+				return err
+			}
+			err = t.createRevision(ctx, tx, article, &before)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, article := range articles {
+		t.articleChanged(pubsub.ActionTypeUpdate, article)
+	}
+	return len(articles), nil
+}
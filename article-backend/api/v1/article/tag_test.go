@@ -0,0 +1,273 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/log"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{
+			name: "nil",
+			tags: nil,
+			want: nil,
+		},
+		{
+			name: "empty",
+			tags: []string{},
+			want: []string{},
+		},
+		{
+			name: "trimmed and lower-cased",
+			tags: []string{" Go ", "WEB"},
+			want: []string{"go", "web"},
+		},
+		{
+			name: "empty tags and duplicates",
+			tags: []string{"web", "", "Go", "  ", "go", "WEB", "api"},
+			want: []string{"web", "go", "api"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, normalizeTags(tt.tags)); diff != "" {
+				t.Errorf("normalizeTags() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	repo := newFakeRepository(
+		&Article{ID: uuid.New(), Version: 1, Title: "go", Slug: "go", Tags: []string{"go"}},
+		&Article{ID: uuid.New(), Version: 1, Title: "go web", Slug: "go-web", Tags: []string{"go", "web"}},
+		&Article{ID: uuid.New(), Version: 1, Title: "web", Slug: "web", Tags: []string{"web"}},
+		&Article{ID: uuid.New(), Version: 1, Title: "untagged", Slug: "untagged"},
+	)
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "single tag",
+			query: "tags=go",
+			want:  []string{"go", "go web"},
+		},
+		{
+			name:  "any tag",
+			query: "tags=go,web",
+			want:  []string{"go", "go web", "web"},
+		},
+		{
+			name:  "all tags",
+			query: "tags=go,web&match=all",
+			want:  []string{"go web"},
+		},
+		{
+			name:  "normalized tags",
+			query: "tags=%20GO%20,,go",
+			want:  []string{"go", "go web"},
+		},
+		{
+			name:  "empty tags",
+			query: "tags=,",
+			want:  []string{"go", "go web", "untagged", "web"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(repo, httptest.NewRequest(http.MethodGet, "/articles/?sort=title:asc&fields=title&"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %v, want %v: %s", w.Code, http.StatusOK, w.Body)
+			}
+
+			list := &ArticleList{}
+			err := json.Unmarshal(w.Body.Bytes(), list)
+			if err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			got := []string{}
+			for _, article := range list.Items {
+				got = append(got, article.Title)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("articles = %v", diff)
+			}
+		})
+	}
+}
+
+func TestReplaceTag(t *testing.T) {
+	tagged := &Article{ID: uuid.New(), Version: 1, Title: "tagged", Slug: "tagged", Tags: []string{"golang", "web"}}
+	merged := &Article{ID: uuid.New(), Version: 1, Title: "merged", Slug: "merged", Tags: []string{"go", "golang"}}
+	trashed := trashedArticle("trashed", time.Now())
+	trashed.Tags = []string{"golang"}
+	other := &Article{ID: uuid.New(), Version: 1, Title: "other", Slug: "other", Tags: []string{"web"}}
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		want     int
+		wantTags map[string][]string
+		// wantVersions are the versions of the articles after the replacement.
+		wantVersions map[string]int64
+	}{
+		{
+			name: "replace",
+			from: "golang",
+			to:   "go",
+			want: 3,
+			wantTags: map[string][]string{
+				"tagged":  {"go", "web"},
+				"merged":  {"go"},
+				"trashed": {"go"},
+				"other":   {"web"},
+			},
+			wantVersions: map[string]int64{"tagged": 2, "merged": 2, "trashed": 2, "other": 1},
+		},
+		{
+			name: "unknown tag",
+			from: "rust",
+			to:   "go",
+			want: 0,
+			wantTags: map[string][]string{
+				"tagged":  {"golang", "web"},
+				"merged":  {"go", "golang"},
+				"trashed": {"golang"},
+				"other":   {"web"},
+			},
+			wantVersions: map[string]int64{"tagged": 1, "merged": 1, "trashed": 1, "other": 1},
+		},
+		{
+			name: "same tag",
+			from: "web",
+			to:   "web",
+			want: 0,
+			wantTags: map[string][]string{
+				"tagged":  {"golang", "web"},
+				"merged":  {"go", "golang"},
+				"trashed": {"golang"},
+				"other":   {"web"},
+			},
+			wantVersions: map[string]int64{"tagged": 1, "merged": 1, "trashed": 1, "other": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(tagged, merged, trashed, other)
+			rt := NewArticleRouter(log.NewZerologWithWriter(io.Discard), repo)
+
+			got, err := rt.replaceTag(context.Background(), tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("replaceTag() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("replaceTag() = %v, want %v", got, tt.want)
+			}
+
+			tags := map[string][]string{}
+			versions := map[string]int64{}
+			for _, article := range fakeRecords[Article](repo) {
+				tags[article.Title] = article.Tags
+				versions[article.Title] = article.Version
+			}
+			if diff := cmp.Diff(tt.wantTags, tags); diff != "" {
+				t.Errorf("tags = %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantVersions, versions); diff != "" {
+				t.Errorf("versions = %v", diff)
+			}
+			if revisions := len(fakeRecords[ArticleRevision](repo)); revisions != tt.want {
+				t.Errorf("recorded %d revisions, want %d", revisions, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenameTag(t *testing.T) {
+	tests := []struct {
+		name       string
+		tags       []string
+		path       string
+		body       string
+		wantStatus int
+		want       *RenameTagResult
+		wantTags   []string
+	}{
+		{
+			name:       "rename",
+			tags:       []string{"golang", "web"},
+			path:       "/tags/golang/rename",
+			body:       `{"name": "Go"}`,
+			wantStatus: http.StatusOK,
+			want:       &RenameTagResult{From: "golang", To: "go", Articles: 1},
+			wantTags:   []string{"go", "web"},
+		},
+		{
+			name:       "encoded slash",
+			tags:       []string{"ci/cd"},
+			path:       "/tags/ci%2Fcd/rename",
+			body:       `{"name": "devops"}`,
+			wantStatus: http.StatusOK,
+			want:       &RenameTagResult{From: "ci/cd", To: "devops", Articles: 1},
+			wantTags:   []string{"devops"},
+		},
+		{
+			name:       "encoded percent sign",
+			tags:       []string{"100%"},
+			path:       "/tags/100%25/rename",
+			body:       `{"name": "complete"}`,
+			wantStatus: http.StatusOK,
+			want:       &RenameTagResult{From: "100%", To: "complete", Articles: 1},
+			wantTags:   []string{"complete"},
+		},
+		{
+			name:       "empty name",
+			tags:       []string{"go"},
+			path:       "/tags/go/rename",
+			body:       `{"name": " "}`,
+			wantStatus: http.StatusBadRequest,
+			wantTags:   []string{"go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(&Article{ID: uuid.New(), Version: 1, Title: "tagged", Slug: "tagged", Tags: tt.tags})
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := serve(repo, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.want != nil {
+				got := &RenameTagResult{}
+				err := json.Unmarshal(w.Body.Bytes(), got)
+				if err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if diff := cmp.Diff(tt.want, got); diff != "" {
+					t.Errorf("rename = %v", diff)
+				}
+			}
+			if diff := cmp.Diff(tt.wantTags, fakeRecords[Article](repo)[0].Tags); diff != "" {
+				t.Errorf("tags = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/db"
+	"github.com/leonsteinhaeuser/example-app/internal/pubsub"
+	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultTrashRetention is the default time deleted articles are kept in the trash before they are purged.
+	DefaultTrashRetention = 30 * 24 * time.Hour
+	// DefaultPurgeInterval is the default interval in which expired articles are purged from the trash.
+	DefaultPurgeInterval = time.Hour
+
+	// purgeBatchSize is the maximum number of articles purged per transaction.
+	purgeBatchSize = 100
+)
+
+var (
+	errArticleNotInTrash = fmt.Errorf("article %w in trash", db.ErrNotFound)
+)
+
+// getTrash returns the deleted articles, most recently deleted first.
+// Optional query parameters:
+// - limit: int (default: 50, max: 500)
+// - offset: int
+func (t *articleRouter) getTrash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	limit := defaultListLimit
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	offset := 0
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	articles := []*Article{}
+	err := t.db.Find(&articles).
+		Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at", true).
+		Order("id", false).
+		Offset(offset).
+		Limit(limit).
+		Commit(ctx)
+	if err != nil {
+		t.writeError(w, "failed to list deleted articles", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, ArticleList{
+		Items: articles,
+	})
+}
+
+// restoreArticle moves a deleted article out of the trash and records a revision of the restore.
+func (t *articleRouter) restoreArticle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	ifMatch := r.Header.Get("If-Match")
+	var article *Article
+	err := t.db.Transaction(ctx, func(tx db.Repository) error {
+		deleted := []*Article{}
+		err := tx.Find(&deleted).Unscoped().Where("id = ?", id).Where("deleted_at IS NOT NULL").Limit(1).ForUpdate(false).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		if len(deleted) == 0 {
+			return errArticleNotInTrash
+		}
+		article = deleted[0]
+		err = checkIfMatch(ifMatch, article)
+		if err != nil {
+			return err
+		}
+
+		previous := *article
+		article.DeletedAt = gorm.DeletedAt{}
+		article.Version++
+		err = tx.Update(article).Unscoped().Select("deleted_at", "version").Commit(ctx)
+		if err != nil {
+			return err
+		}
+		// the restore bumps the version, so it is recorded like any other change
+		return t.createRevision(ctx, tx, article, &previous)
+	})
+	if errors.Is(err, errPreconditionFailed) {
+		utils.WriteJSON(w, http.StatusPreconditionFailed, server.Error{
+			Status:  http.StatusPreconditionFailed,
+			Message: "failed to restore article",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		t.writeError(w, "failed to restore article", err)
+		return
+	}
+
+	t.articleChanged(pubsub.ActionTypeUpdate, article)
+	w.Header().Set("ETag", articleETag(article))
+	utils.WriteJSON(w, http.StatusOK, article)
+}
+
+// RunPurgeScheduler permanently removes articles, including their revisions, previous slugs, comments and attachments,
+// that have been in the trash for longer than retention.
+// It checks for expired articles every interval and returns when ctx is canceled.
+func (t *articleRouter) RunPurgeScheduler(ctx context.Context, interval, retention time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPurgeInterval
+	}
+	if retention <= 0 {
+		retention = DefaultTrashRetention
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				purged, err := t.purgeDeleted(ctx, time.Now().Add(-retention))
+				if err != nil {
+					t.log.Error(err).Log("failed to purge deleted articles")
+					break
+				}
+				if purged > 0 {
+					t.log.Info().Field("count", purged).Log("purged deleted articles")
+				}
+				if purged < purgeBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// purgeDeleted permanently removes a batch of articles deleted before the given time
+// and returns the number of removed articles.
+func (t *articleRouter) purgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	attachments := []*Attachment{}
+	err := t.db.Transaction(ctx, func(tx db.Repository) error {
+		expired := []*Article{}
+		// skip articles locked by other replicas, they are purged there
+		err := tx.Find(&expired).
+			Unscoped().
+			Select("id").
+			Where("deleted_at < ?", before).
+			Limit(purgeBatchSize).
+			ForUpdate(true).
+			Commit(ctx)
+		if err != nil {
+			return err
+		}
+		if len(expired) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(expired))
+		for _, article := range expired {
+			ids = append(ids, article.ID)
+		}
+		err = tx.Delete(&ArticleRevision{}).Where("article_id IN ?", ids).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		err = tx.Delete(&ArticleSlug{}).Where("article_id IN ?", ids).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		err = tx.Delete(&Comment{}).Unscoped().Where("article_id IN ?", ids).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		err = tx.Find(&attachments).Where("article_id IN ?", ids).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		err = tx.Delete(&Attachment{}).Where("article_id IN ?", ids).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		err = tx.Delete(&Article{}).Unscoped().Where("id IN ?", ids).Commit(ctx)
+		if err != nil {
+			return err
+		}
+		purged = len(expired)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	// the content is only removed once the attachments are gone, so it is never missing for a stored attachment
+	if t.attachments != nil {
+		t.deleteAttachmentContent(ctx, attachments...)
+	}
+	return purged, nil
+}
@@ -0,0 +1,219 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/leonsteinhaeuser/example-app/internal/blob"
+	"github.com/leonsteinhaeuser/example-app/internal/log"
+	"gorm.io/gorm"
+)
+
+// trashedArticle returns an article with the given title that was deleted at the given time.
+func trashedArticle(title string, deleted time.Time) *Article {
+	return &Article{
+		ID:        uuid.New(),
+		Version:   1,
+		Title:     title,
+		Slug:      title,
+		DeletedAt: gorm.DeletedAt{Time: deleted, Valid: true},
+	}
+}
+
+func TestGetTrash(t *testing.T) {
+	now := time.Now()
+	repo := newFakeRepository(
+		&Article{ID: uuid.New(), Version: 1, Title: "active", Slug: "active"},
+		trashedArticle("older", now.Add(-2*time.Hour)),
+		trashedArticle("newer", now.Add(-time.Hour)),
+		trashedArticle("oldest", now.Add(-3*time.Hour)),
+	)
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name: "most recently deleted first",
+			want: []string{"newer", "older", "oldest"},
+		},
+		{
+			name:  "page",
+			query: "?offset=1&limit=1",
+			want:  []string{"older"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(repo, httptest.NewRequest(http.MethodGet, "/articles/trash"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %v, want %v: %s", w.Code, http.StatusOK, w.Body)
+			}
+
+			list := &ArticleList{}
+			err := json.Unmarshal(w.Body.Bytes(), list)
+			if err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			got := []string{}
+			for _, article := range list.Items {
+				got = append(got, article.Title)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("trash = %v", diff)
+			}
+		})
+	}
+}
+
+func TestRestoreArticle(t *testing.T) {
+	active := &Article{ID: uuid.New(), Version: 1, Title: "active", Slug: "active"}
+	trashed := trashedArticle("trashed", time.Now())
+	tests := []struct {
+		name       string
+		article    *Article
+		ifMatch    string
+		wantStatus int
+		// wantDeleted is true if the article is still in the trash after the request.
+		wantDeleted   bool
+		wantVersion   int64
+		wantRevisions int
+	}{
+		{
+			name:          "restore",
+			article:       trashed,
+			wantStatus:    http.StatusOK,
+			wantVersion:   2,
+			wantRevisions: 1,
+		},
+		{
+			name:          "restore current version",
+			article:       trashed,
+			ifMatch:       `"1"`,
+			wantStatus:    http.StatusOK,
+			wantVersion:   2,
+			wantRevisions: 1,
+		},
+		{
+			name:        "modified article",
+			article:     trashed,
+			ifMatch:     `"2"`,
+			wantStatus:  http.StatusPreconditionFailed,
+			wantDeleted: true,
+			wantVersion: 1,
+		},
+		{
+			name:        "article not in trash",
+			article:     active,
+			wantStatus:  http.StatusNotFound,
+			wantVersion: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(active, trashed)
+			r := httptest.NewRequest(http.MethodPost, "/articles/"+tt.article.ID.String()+"/restore", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := serve(repo, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			for _, article := range fakeRecords[Article](repo) {
+				if article.ID != tt.article.ID {
+					continue
+				}
+				if article.DeletedAt.Valid != tt.wantDeleted || article.Version != tt.wantVersion {
+					t.Errorf("article deleted = %v, version = %v, want %v, %v", article.DeletedAt.Valid, article.Version, tt.wantDeleted, tt.wantVersion)
+				}
+			}
+			if got := len(fakeRecords[ArticleRevision](repo)); got != tt.wantRevisions {
+				t.Errorf("recorded %d revisions, want %d", got, tt.wantRevisions)
+			}
+		})
+	}
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	now := time.Now()
+	active := &Article{ID: uuid.New(), Version: 1, Title: "active", Slug: "active"}
+	recent := trashedArticle("recent", now.Add(-time.Hour))
+	expired := trashedArticle("expired", now.Add(-48*time.Hour))
+
+	records := []any{active, recent, expired}
+	attachments := []*Attachment{}
+	for _, article := range []*Article{active, recent, expired} {
+		attachment := &Attachment{ID: uuid.New(), ArticleID: article.ID, Filename: "notes.txt"}
+		attachments = append(attachments, attachment)
+		records = append(records,
+			&ArticleRevision{ID: uuid.New(), ArticleID: article.ID, Revision: 1, Article: *article},
+			&ArticleSlug{Slug: "old-" + article.Title, ArticleID: article.ID},
+			&Comment{ID: uuid.New(), ArticleID: article.ID, Content: "comment"},
+			attachment,
+		)
+	}
+	repo := newFakeRepository(records...)
+	store, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	for _, attachment := range attachments {
+		err = store.Put(context.Background(), attachmentKey(attachment.ArticleID, attachment.ID), strings.NewReader("content"))
+		if err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+	rt := NewArticleRouter(log.NewZerologWithWriter(io.Discard), repo, WithAttachments(store, 64))
+
+	got, err := rt.purgeDeleted(context.Background(), now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("purgeDeleted() error = %v", err)
+	}
+	if got != 1 {
+		t.Errorf("purgeDeleted() = %v, want 1", got)
+	}
+
+	// the article, its revision, slug, comment, attachment and content are left of the articles that did not expire
+	want := map[uuid.UUID]int{active.ID: 6, recent.ID: 6}
+	remaining := map[uuid.UUID]int{}
+	for _, article := range fakeRecords[Article](repo) {
+		remaining[article.ID]++
+	}
+	for _, revision := range fakeRecords[ArticleRevision](repo) {
+		remaining[revision.ArticleID]++
+	}
+	for _, slug := range fakeRecords[ArticleSlug](repo) {
+		remaining[slug.ArticleID]++
+	}
+	for _, comment := range fakeRecords[Comment](repo) {
+		remaining[comment.ArticleID]++
+	}
+	for _, attachment := range fakeRecords[Attachment](repo) {
+		remaining[attachment.ArticleID]++
+	}
+	for _, attachment := range attachments {
+		content, err := store.Open(context.Background(), attachmentKey(attachment.ArticleID, attachment.ID))
+		if errors.Is(err, blob.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		content.Close()
+		remaining[attachment.ArticleID]++
+	}
+	if diff := cmp.Diff(want, remaining); diff != "" {
+		t.Errorf("remaining records = %v", diff)
+	}
+}
@@ -3,23 +3,41 @@ package main
 import (
 	"context"
 	"net/http"
+	"net/netip"
+	"time"
 
 	"github.com/leonsteinhaeuser/example-app/article-backend/api/v1/article"
+	"github.com/leonsteinhaeuser/example-app/internal/blob"
 	"github.com/leonsteinhaeuser/example-app/internal/db"
 	"github.com/leonsteinhaeuser/example-app/internal/env"
+	"github.com/leonsteinhaeuser/example-app/internal/keystore"
 	"github.com/leonsteinhaeuser/example-app/internal/log"
+	"github.com/leonsteinhaeuser/example-app/internal/pubsub"
 	"github.com/leonsteinhaeuser/example-app/internal/server"
+	"github.com/leonsteinhaeuser/example-app/internal/server/middleware"
 )
 
 var (
 	logr = log.NewZerlog()
 
-	httpServer = server.NewDefaultServer(logr, env.GetStringEnvOrDefault("LISTEN_ADDRESS", ":1200"))
+	httpServer = server.NewDefaultServer(logr, env.GetStringEnvOrDefault("LISTEN_ADDRESS", ":1200"),
+		server.WithTrustedProxies(trustedProxies()...),
+	)
 	httpRouter = server.NewGenericRouter()
 
 	dbr db.Repository
 )
 
+// trustedProxies returns the proxies the user ID header is read from, see server.WithTrustedProxies.
+// Users are unknown, and comments cannot be written or moderated, unless TRUSTED_PROXIES is set.
+func trustedProxies() []netip.Prefix {
+	proxies, err := middleware.ParseTrustedProxies(env.GetStringEnvOrDefault("TRUSTED_PROXIES", ""))
+	if err != nil {
+		panic(err)
+	}
+	return proxies
+}
+
 func init() {
 	db, err := db.NewGormRepository(db.PostgresConfig{
 		Host:     env.GetStringEnvOrDefault("POSTGRES_HOST", "localhost"),
@@ -32,20 +50,77 @@ func init() {
 		panic(err)
 	}
 	dbr = db
-
-	dbr.Migrate(context.Background(), &article.Article{})
 }
 
 func main() {
 	defer dbr.Close(context.Background())
 
+	options := []article.Option{
+		article.WithSearchLanguage(env.GetStringEnvOrDefault("ARTICLE_SEARCH_LANGUAGE", article.DefaultSearchLanguage)),
+		article.WithRequireIfMatch(env.GetBoolEnvOrDefault("ARTICLE_REQUIRE_IF_MATCH", false)),
+		article.WithFeed(
+			env.GetStringEnvOrDefault("ARTICLE_FEED_TITLE", article.DefaultFeedTitle),
+			env.GetStringEnvOrDefault("ARTICLE_FEED_ARTICLE_URL", ""),
+		),
+	}
+	// events are only published if a NATS server is configured
+	if natsAddress := env.GetStringEnvOrDefault("NATS_ADDRESS", ""); natsAddress != "" {
+		publisher, err := pubsub.NewNatsClient(natsAddress, env.GetStringEnvOrDefault("ARTICLE_EVENT_TOPIC", article.DefaultEventTopic))
+		if err != nil {
+			panic(err)
+		}
+		defer publisher.Close(context.Background())
+		options = append(options, article.WithPublisher(publisher))
+	}
+	// articles are only cached if a redis server is configured
+	if redisAddress := env.GetStringEnvOrDefault("REDIS_ADDRESS", ""); redisAddress != "" {
+		cache, err := keystore.NewRedisKeyStore(keystore.RedisConfig{
+			Driver:     env.GetStringEnvOrDefault("REDIS_DRIVER", string(keystore.RedisDriverRedis)),
+			ClientName: "article-backend",
+			Address:    redisAddress,
+			Username:   env.GetStringEnvOrDefault("REDIS_USERNAME", ""),
+			Password:   env.GetStringEnvOrDefault("REDIS_PASSWORD", ""),
+			DB:         env.GetIntEnvOrDefault("REDIS_DB", 0),
+		})
+		if err != nil {
+			panic(err)
+		}
+		options = append(options, article.WithCache(cache,
+			time.Duration(env.GetIntEnvOrDefault("ARTICLE_CACHE_TTL_SEC", int(article.DefaultCacheTTL.Seconds())))*time.Second,
+		))
+	}
+
+	// attachments are only enabled if a directory to store them in is configured
+	if attachmentDir := env.GetStringEnvOrDefault("ARTICLE_ATTACHMENT_DIR", ""); attachmentDir != "" {
+		store, err := blob.NewFileStore(attachmentDir)
+		if err != nil {
+			panic(err)
+		}
+		options = append(options, article.WithAttachments(store,
+			int64(env.GetIntEnvOrDefault("ARTICLE_ATTACHMENT_MAX_SIZE_MB", article.DefaultMaxAttachmentSize>>20))<<20,
+		))
+	}
+
+	articleRouter := article.NewArticleRouter(logr, dbr, options...)
+	err := articleRouter.Migrate(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	go articleRouter.RunPublishScheduler(context.Background(),
+		time.Duration(env.GetIntEnvOrDefault("ARTICLE_PUBLISH_INTERVAL_SEC", int(article.DefaultPublishInterval.Seconds())))*time.Second,
+	)
+	go articleRouter.RunPurgeScheduler(context.Background(),
+		time.Duration(env.GetIntEnvOrDefault("ARTICLE_PURGE_INTERVAL_SEC", int(article.DefaultPurgeInterval.Seconds())))*time.Second,
+		time.Duration(env.GetIntEnvOrDefault("ARTICLE_TRASH_RETENTION_DAYS", int(article.DefaultTrashRetention.Hours()/24)))*24*time.Hour,
+	)
+
 	httpRouter.AddEndpoint("GET", "/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	})
 	httpServer.AddRouter(httpRouter)
-	httpServer.AddRouter(article.NewArticleRouter(logr, dbr))
-	err := httpServer.Start()
+	httpServer.AddRouter(articleRouter)
+	err = httpServer.Start()
 	if err != nil {
 		panic(err)
 	}
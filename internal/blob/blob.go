@@ -0,0 +1,34 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrNotFound is returned by Open if the blob does not exist.
+	ErrNotFound = errors.New("blob not found")
+	// ErrInvalidKey is returned for keys that are empty or not a clean, relative, slash-separated path.
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store stores binary objects by key. Keys are slash-separated paths like "articles/1/image".
+type Store interface {
+	Puter
+	Opener
+	// Delete removes the blob stored under key. Deleting a blob that does not exist is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+type Puter interface {
+	// Put stores the content read from r under key, replacing an existing blob.
+	// The blob is only stored if r is read completely, so a failing read leaves the previous state.
+	Put(ctx context.Context, key string, r io.Reader) error
+}
+
+type Opener interface {
+	// Open returns the content of the blob stored under key. It returns ErrNotFound if the blob does not exist.
+	// The content is seekable, so it can be served with range requests. It must be closed by the caller.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+}
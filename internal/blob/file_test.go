@@ -0,0 +1,182 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// failingReader returns its content followed by an error.
+type failingReader struct {
+	content io.Reader
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.content.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestFileStore_path(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "nested key",
+			key:  "articles/1/image",
+			want: "/blobs/articles/1/image",
+		},
+		{
+			name:    "empty key",
+			key:     "",
+			wantErr: ErrInvalidKey,
+		},
+		{
+			name:    "root",
+			key:     ".",
+			wantErr: ErrInvalidKey,
+		},
+		{
+			name:    "absolute key",
+			key:     "/etc/passwd",
+			wantErr: ErrInvalidKey,
+		},
+		{
+			name:    "parent directory",
+			key:     "articles/../../etc/passwd",
+			wantErr: ErrInvalidKey,
+		},
+		{
+			name:    "backslash",
+			key:     `articles\..\image`,
+			wantErr: ErrInvalidKey,
+		},
+		{
+			name:    "trailing slash",
+			key:     "articles/",
+			wantErr: ErrInvalidKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fileStore{root: "/blobs"}
+			got, err := f.path(tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("path() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("path() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	type put struct {
+		key     string
+		content io.Reader
+		wantErr bool
+	}
+	tests := []struct {
+		name    string
+		puts    []put
+		deletes []string
+		key     string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "stored blob",
+			puts: []put{{key: "articles/1/image", content: strings.NewReader("content")}},
+			key:  "articles/1/image",
+			want: "content",
+		},
+		{
+			name: "replaced blob",
+			puts: []put{
+				{key: "image", content: strings.NewReader("old")},
+				{key: "image", content: strings.NewReader("new")},
+			},
+			key:  "image",
+			want: "new",
+		},
+		{
+			name: "failed put keeps previous blob",
+			puts: []put{
+				{key: "image", content: strings.NewReader("old")},
+				{key: "image", content: &failingReader{content: strings.NewReader("partial")}, wantErr: true},
+			},
+			key:  "image",
+			want: "old",
+		},
+		{
+			name:    "missing blob",
+			key:     "image",
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "directory",
+			puts:    []put{{key: "articles/1/image", content: strings.NewReader("content")}},
+			key:     "articles/1",
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "deleted blob",
+			puts:    []put{{key: "image", content: strings.NewReader("content")}},
+			deletes: []string{"image", "image"},
+			key:     "image",
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "invalid key",
+			key:     "../image",
+			wantErr: ErrInvalidKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, err := NewFileStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewFileStore() error = %v", err)
+			}
+			for _, p := range tt.puts {
+				err := store.Put(ctx, p.key, p.content)
+				if (err != nil) != p.wantErr {
+					t.Fatalf("Put() error = %v, wantErr %v", err, p.wantErr)
+				}
+			}
+			for _, key := range tt.deletes {
+				err := store.Delete(ctx, key)
+				if err != nil {
+					t.Fatalf("Delete() error = %v", err)
+				}
+			}
+
+			blob, err := store.Open(ctx, tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Open() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer blob.Close()
+			got, err := io.ReadAll(blob)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("Open() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
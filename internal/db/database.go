@@ -1,27 +1,88 @@
 package db
 
-import "context"
+import (
+	"context"
+	"database/sql"
+)
 
+// TX is a query builder. It records clauses and runs them as a single statement when Commit is called.
+// A TX must not be reused after Commit.
 type TX interface {
 	// Where adds a where clause to the query.
-	Where(field string, value any) TX
+	// The query may contain "?" placeholders that are replaced by args.
+	Where(query string, args ...any) TX
 	// Or adds an or clause to the query.
-	Or(field string, value any) TX
+	Or(query string, args ...any) TX
 	// Not adds a not clause to the query.
-	Not(field string, value any) TX
+	Not(query string, args ...any) TX
+	// Select sets the columns or expressions the query reads, or the columns an update writes.
+	// The query may contain "?" placeholders that are replaced by args.
+	// If args are column names, they are selected in addition to query.
+	Select(query string, args ...any) TX
+	// Order orders the result by column. Multiple calls add further order columns.
+	// The column must not be user input, since it is not escaped.
+	Order(column string, desc bool) TX
 	// Limit adds a limit clause to the query.
 	Limit(limit int) TX
+	// Offset skips the given number of records.
+	Offset(offset int) TX
+	// ForUpdate locks the selected records until the end of the surrounding transaction.
+	// If skipLocked is true, records locked by other transactions are skipped instead of waited for.
+	ForUpdate(skipLocked bool) TX
+	// ForKeyShare locks the selected records against deletion and changes of their keys until the end of the
+	// surrounding transaction. Other transactions may still read, lock with ForKeyShare and update other columns.
+	ForKeyShare() TX
+	// Unscoped includes soft deleted records in the query.
+	// Deleting records of a soft deletable type with Unscoped removes them permanently.
+	Unscoped() TX
+	// Batches executes a find query in batches of size records, ordered by primary key.
+	// Each batch is read into the data passed to Find, after which fn is called.
+	// The records are not held in memory all at once, which allows to stream large results.
+	// If fn returns an error, no further batches are read and the error is returned.
+	// Batches must not be combined with Order, since the batches are paginated by primary key.
+	Batches(ctx context.Context, size int, fn func() error) error
+	// RequireMatch makes Commit return ErrNotFound if a find, update or delete matches no records.
+	RequireMatch() TX
 	// Commit executes the query.
+	// The statement is bound to ctx and aborted once ctx is canceled or its deadline is exceeded.
+	// Errors of the database are reported as ErrNotFound, ErrConflict or ErrInvalidInput where applicable.
 	Commit(ctx context.Context) error
 }
 
 // Repository represents an interface between the application and the database.
+// Like Commit, its methods report errors of the database as ErrNotFound, ErrConflict or ErrInvalidInput
+// where applicable.
 type Repository interface {
 	Create(ctx context.Context, data any) error
+	// Find returns a TX that reads the matching records into data.
 	Find(data any) TX
+	// Update returns a TX that writes the non-zero fields of data to the matching records.
 	Update(data any) TX
+	// Delete returns a TX that deletes the matching records of the type of data.
+	// Records of types with a gorm.DeletedAt field are soft deleted, unless the TX is unscoped.
 	Delete(data any) TX
+	// Count returns a TX that counts the matching records of the type of model into count.
+	Count(model any, count *int64) TX
+	// Raw executes the given SQL statement.
 	Raw(ctx context.Context, query string, args ...any) error
+	// Query executes the given SQL query and scans the resulting rows into dest.
+	// It is meant for queries the TX builder cannot express, like aggregations.
+	Query(ctx context.Context, dest any, query string, args ...any) error
 	Migrate(ctx context.Context, model any) error
+	// Transaction runs fn inside a database transaction.
+	// The Repository passed to fn is bound to the transaction. The transaction is committed
+	// if fn returns nil and rolled back if fn returns an error or panics.
+	// Calling Transaction on a Repository that is already bound to a transaction
+	// creates a savepoint instead, in which case opts are ignored.
+	Transaction(ctx context.Context, fn func(tx Repository) error, opts ...TxOptions) error
 	Close(context.Context) error
 }
+
+// TxOptions configures a transaction started by Repository.Transaction.
+type TxOptions struct {
+	// Isolation is the isolation level of the transaction.
+	// The zero value uses the default isolation level of the database.
+	Isolation sql.IsolationLevel
+	// ReadOnly marks the transaction as read-only.
+	ReadOnly bool
+}
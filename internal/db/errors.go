@@ -0,0 +1,59 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/leonsteinhaeuser/example-app/internal/errs"
+	"gorm.io/gorm"
+)
+
+// The errors of the database are the shared errors of package errs, so that they can be mapped
+// to responses without depending on this package.
+var (
+	// ErrNotFound is returned if a statement required to match records did not match any.
+	ErrNotFound = errs.ErrNotFound
+	// ErrConflict is returned if a statement violates a unique, foreign key or exclusion constraint.
+	ErrConflict = errs.ErrConflict
+	// ErrInvalidInput is returned if the database rejects a value, e.g. a malformed UUID or a missing required value.
+	ErrInvalidInput = errs.ErrInvalidInput
+)
+
+// translateError wraps errors of the database in the matching sentinel error of this package.
+// The original error stays accessible with errors.As.
+func translateError(err error) error {
+	if err == nil || errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) || errors.Is(err, ErrInvalidInput) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	pgErr := &pgconn.PgError{}
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	// see https://www.postgresql.org/docs/current/errcodes-appendix.html
+	switch {
+	case pgErr.Code == "23505", pgErr.Code == "23503", pgErr.Code == "23P01":
+		// unique_violation, foreign_key_violation, exclusion_violation
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case pgErr.Code == "23502", pgErr.Code == "23514", strings.HasPrefix(pgErr.Code, "22"):
+		// not_null_violation, check_violation, data exceptions like invalid_text_representation
+		return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	default:
+		return err
+	}
+}
+
+// ConflictConstraint returns the name of the constraint violated by a statement that failed with ErrConflict.
+// It returns an empty string if err is no conflict reported by the database.
+func ConflictConstraint(err error) string {
+	pgErr := &pgconn.PgError{}
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &pgErr) {
+		return ""
+	}
+	return pgErr.ConstraintName
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestTranslateError(t *testing.T) {
+	errOther := errors.New("other")
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "nil",
+			err:     nil,
+			wantErr: nil,
+		},
+		{
+			name:    "record not found",
+			err:     gorm.ErrRecordNotFound,
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "unique violation",
+			err:     &pgconn.PgError{Code: "23505"},
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "wrapped foreign key violation",
+			err:     fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23503"}),
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "invalid text representation",
+			err:     &pgconn.PgError{Code: "22P02"},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "not null violation",
+			err:     &pgconn.PgError{Code: "23502"},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "other database error",
+			err:     &pgconn.PgError{Code: "40001"},
+			wantErr: nil,
+		},
+		{
+			name:    "other error",
+			err:     errOther,
+			wantErr: errOther,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateError(tt.err)
+			if tt.wantErr == nil && got != tt.err {
+				t.Errorf("translateError() = %v, want %v", got, tt.err)
+			}
+			if tt.wantErr != nil && !errors.Is(got, tt.wantErr) {
+				t.Errorf("translateError() = %v, want %v", got, tt.wantErr)
+			}
+			// the original error must stay accessible
+			if tt.err != nil && !errors.Is(got, tt.err) {
+				t.Errorf("translateError() = %v, does not wrap %v", got, tt.err)
+			}
+		})
+	}
+}
+
+func TestConflictConstraint(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: "",
+		},
+		{
+			name: "unique violation",
+			err:  translateError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_articles_slug"}),
+			want: "idx_articles_slug",
+		},
+		{
+			name: "wrapped unique violation",
+			err:  fmt.Errorf("create: %w", translateError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_articles_slug"})),
+			want: "idx_articles_slug",
+		},
+		{
+			name: "invalid input",
+			err:  translateError(&pgconn.PgError{Code: "23502", ConstraintName: "articles_title_not_null"}),
+			want: "",
+		},
+		{
+			name: "conflict without database error",
+			err:  ErrConflict,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConflictConstraint(tt.err); got != tt.want {
+				t.Errorf("ConflictConstraint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
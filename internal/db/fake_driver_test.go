@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// fakeDriver is a database/sql driver that records the statements it receives.
+// Every statement succeeds. Queries return the queued results in order and no rows once they are used up.
+// Executed statements affect the queued number of rows in order and one row once they are used up.
+type fakeDriver struct {
+	mu         sync.Mutex
+	statements []string
+	txOptions  []driver.TxOptions
+	results    []fakeResult
+	affected   []int64
+}
+
+// fakeResult is the result of a query.
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// newFakeGormRepository returns a gormRepository backed by a fakeDriver.
+func newFakeGormRepository(t *testing.T) (*gormRepository, *fakeDriver) {
+	t.Helper()
+	fd := &fakeDriver{}
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fd)}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open fake database: %v", err)
+	}
+	return &gormRepository{DB: gormDB}, fd
+}
+
+func (f *fakeDriver) record(statement string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, statement)
+}
+
+// Statements returns the statements received so far.
+func (f *fakeDriver) Statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.statements...)
+}
+
+// Connect implements driver.Connector.
+func (f *fakeDriver) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{driver: f}, nil
+}
+
+// Driver implements driver.Connector.
+func (f *fakeDriver) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.driver.mu.Lock()
+	c.driver.txOptions = append(c.driver.txOptions, opts)
+	c.driver.mu.Unlock()
+	c.driver.record("BEGIN")
+	return &fakeTx{driver: c.driver}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.record(query)
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	if len(c.driver.affected) == 0 {
+		return driver.RowsAffected(1), nil
+	}
+	affected := c.driver.affected[0]
+	c.driver.affected = c.driver.affected[1:]
+	return driver.RowsAffected(affected), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.record(query)
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	if len(c.driver.results) == 0 {
+		return &fakeRows{}, nil
+	}
+	result := c.driver.results[0]
+	c.driver.results = c.driver.results[1:]
+	return &fakeRows{result: result}, nil
+}
+
+type fakeTx struct {
+	driver *fakeDriver
+}
+
+func (t *fakeTx) Commit() error {
+	t.driver.record("COMMIT")
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.driver.record("ROLLBACK")
+	return nil
+}
+
+type fakeRows struct {
+	result fakeResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.result.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -93,35 +94,60 @@ func NewGormRepository(conf PostgresConfig) (Repository, error) {
 // gormRepository implements the Repository interface.
 type gormRepository struct {
 	DB *gorm.DB
+
+	// inTransaction is true if DB is bound to a transaction.
+	inTransaction bool
 }
 
 func (p *gormRepository) Create(ctx context.Context, data any) error {
-	return p.DB.Model(data).Create(data).Error
+	return translateError(p.DB.WithContext(ctx).Model(data).Create(data).Error)
 }
 
 func (p *gormRepository) Find(data any) TX {
-	return &gormTX{
-		tx: p.DB.Model(data).Find(data),
-	}
+	return newGormTX(p.DB, gormOperationFind, data)
 }
 
 func (p *gormRepository) Update(data any) TX {
-	return &gormTX{
-		tx: p.DB.Model(data).Updates(data),
-	}
+	return newGormTX(p.DB, gormOperationUpdate, data)
 }
 
 func (p *gormRepository) Delete(data any) TX {
-	return &gormTX{
-		tx: p.DB.Model(data).Delete(data),
-	}
+	return newGormTX(p.DB, gormOperationDelete, data)
+}
+
+func (p *gormRepository) Count(model any, count *int64) TX {
+	tx := newGormTX(p.DB, gormOperationCount, model)
+	tx.count = count
+	return tx
 }
 
 func (p *gormRepository) Migrate(ctx context.Context, model any) error {
-	return p.DB.AutoMigrate(model)
+	return p.DB.WithContext(ctx).AutoMigrate(model)
 }
 
+func (p *gormRepository) Transaction(ctx context.Context, fn func(tx Repository) error, opts ...TxOptions) error {
+	sqlOpts := []*sql.TxOptions{}
+	for _, opt := range opts {
+		sqlOpts = append(sqlOpts, &sql.TxOptions{
+			Isolation: opt.Isolation,
+			ReadOnly:  opt.ReadOnly,
+		})
+	}
+	// gorm creates a savepoint if DB is already bound to a transaction
+	return p.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormRepository{
+			DB:            tx,
+			inTransaction: true,
+		})
+	}, sqlOpts...)
+}
+
+// Close closes the underlying connection pool.
+// Close is a no-op for repositories bound to a transaction, since they share the pool of their parent.
 func (p *gormRepository) Close(context.Context) error {
+	if p.inTransaction {
+		return nil
+	}
 	sqlDB, err := p.DB.DB()
 	if err != nil {
 		return err
@@ -130,9 +156,9 @@ func (p *gormRepository) Close(context.Context) error {
 }
 
 func (p *gormRepository) Raw(ctx context.Context, query string, args ...any) error {
-	return p.DB.Raw(query, args).Error
+	return translateError(p.DB.WithContext(ctx).Exec(query, args...).Error)
 }
 
-func (p *gormRepository) FindV2(ctx context.Context, data any, tx TX) {
-	p.DB.Find(data)
+func (p *gormRepository) Query(ctx context.Context, dest any, query string, args ...any) error {
+	return translateError(p.DB.WithContext(ctx).Raw(query, args...).Scan(dest).Error)
 }
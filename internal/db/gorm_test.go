@@ -1,10 +1,18 @@
 package db
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestPosgresConfigFromEnv(t *testing.T) {
@@ -172,3 +180,114 @@ func TestPostgresConfig_dsn(t *testing.T) {
 		})
 	}
 }
+
+func TestGormRepository_Transaction(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name           string
+		fn             func(ctx context.Context, tx Repository) error
+		opts           []TxOptions
+		wantErr        error
+		wantStatements []string
+		wantTxOptions  driver.TxOptions
+	}{
+		{
+			name: "commit",
+			fn: func(ctx context.Context, tx Repository) error {
+				return tx.Delete(&testModel{}).Where("id = ?", 1).Commit(ctx)
+			},
+			wantErr:        nil,
+			wantStatements: []string{"BEGIN", "DELETE", "COMMIT"},
+		},
+		{
+			name: "rollback on error",
+			fn: func(ctx context.Context, tx Repository) error {
+				err := tx.Delete(&testModel{}).Where("id = ?", 1).Commit(ctx)
+				if err != nil {
+					return err
+				}
+				return errFailed
+			},
+			wantErr:        errFailed,
+			wantStatements: []string{"BEGIN", "DELETE", "ROLLBACK"},
+		},
+		{
+			name: "nested transaction rolls back to savepoint",
+			fn: func(ctx context.Context, tx Repository) error {
+				err := tx.Transaction(ctx, func(tx Repository) error {
+					return errFailed
+				})
+				if !errors.Is(err, errFailed) {
+					return fmt.Errorf("unexpected error: %w", err)
+				}
+				return tx.Delete(&testModel{}).Where("id = ?", 1).Commit(ctx)
+			},
+			wantErr:        nil,
+			wantStatements: []string{"BEGIN", "SAVEPOINT", "ROLLBACK TO SAVEPOINT", "DELETE", "COMMIT"},
+		},
+		{
+			name: "isolation level",
+			fn: func(ctx context.Context, tx Repository) error {
+				return nil
+			},
+			opts:           []TxOptions{{Isolation: sql.LevelSerializable, ReadOnly: true}},
+			wantErr:        nil,
+			wantStatements: []string{"BEGIN", "COMMIT"},
+			wantTxOptions: driver.TxOptions{
+				Isolation: driver.IsolationLevel(sql.LevelSerializable),
+				ReadOnly:  true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo, fd := newFakeGormRepository(t)
+
+			err := repo.Transaction(ctx, func(tx Repository) error {
+				return tt.fn(ctx, tx)
+			}, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("gormRepository.Transaction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// only compare the statement keyword, since savepoint names are generated
+			got := []string{}
+			for _, stmt := range fd.Statements() {
+				switch {
+				case strings.HasPrefix(stmt, "ROLLBACK TO SAVEPOINT"):
+					got = append(got, "ROLLBACK TO SAVEPOINT")
+				case strings.HasPrefix(stmt, "SAVEPOINT"):
+					got = append(got, "SAVEPOINT")
+				default:
+					got = append(got, strings.Fields(stmt)[0])
+				}
+			}
+			if diff := cmp.Diff(tt.wantStatements, got); diff != "" {
+				t.Errorf("gormRepository.Transaction() statements = %v", diff)
+			}
+			if len(fd.txOptions) != 1 {
+				t.Fatalf("gormRepository.Transaction() started %d transactions, want 1", len(fd.txOptions))
+			}
+			if fd.txOptions[0] != tt.wantTxOptions {
+				t.Errorf("gormRepository.Transaction() options = %v, want %v", fd.txOptions[0], tt.wantTxOptions)
+			}
+		})
+	}
+}
+
+func TestGormRepository_Query(t *testing.T) {
+	ctx := context.Background()
+	repo, fd := newFakeGormRepository(t)
+
+	dest := []testModel{}
+	err := repo.Query(ctx, &dest, "SELECT name, count(*) FROM test_models WHERE id > ? GROUP BY name", 1)
+	if err != nil {
+		t.Fatalf("gormRepository.Query() error = %v", err)
+	}
+	want := []string{"SELECT name, count(*) FROM test_models WHERE id > $1 GROUP BY name"}
+	if diff := cmp.Diff(want, fd.Statements()); diff != "" {
+		t.Errorf("gormRepository.Query() statements = %v", diff)
+	}
+}
@@ -4,36 +4,197 @@ import (
 	"context"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
 	_ TX = (*gormTX)(nil)
 )
 
+// gormOperation is the statement a gormTX executes on commit.
+type gormOperation int
+
+const (
+	gormOperationFind gormOperation = iota
+	gormOperationUpdate
+	gormOperationDelete
+	gormOperationCount
+)
+
+// gormClauseType is the kind of a recorded gormClause.
+type gormClauseType int
+
+const (
+	gormClauseWhere gormClauseType = iota
+	gormClauseOr
+	gormClauseNot
+	gormClauseOrder
+	gormClauseSelect
+)
+
+// gormClause is a clause recorded by a gormTX.
+type gormClause struct {
+	kind  gormClauseType
+	query string
+	args  []any
+	desc  bool
+}
+
+// gormTX records the clauses of a query and runs them as a single statement on Commit.
+// A gormTX does not touch the database before Commit is called.
 type gormTX struct {
-	tx *gorm.DB
+	db        *gorm.DB
+	operation gormOperation
+	data      any
+	// count receives the result of a count operation.
+	count *int64
+
+	clauses []gormClause
+	limit   int
+	offset  int
+	// locking is the row locking clause of the query, if any.
+	locking *clause.Locking
+	// unscoped disables the soft delete handling of gorm.
+	unscoped bool
+	// requireMatch turns statements without matching records into ErrNotFound.
+	requireMatch bool
 }
 
-func (g *gormTX) Where(field string, value any) TX {
-	g.tx = g.tx.Where(field, value)
+func newGormTX(db *gorm.DB, operation gormOperation, data any) *gormTX {
+	return &gormTX{
+		db:        db,
+		operation: operation,
+		data:      data,
+		limit:     -1,
+	}
+}
+
+func (g *gormTX) Where(query string, args ...any) TX {
+	g.clauses = append(g.clauses, gormClause{kind: gormClauseWhere, query: query, args: args})
 	return g
 }
 
-func (g *gormTX) Or(field string, value any) TX {
-	g.tx = g.tx.Or(field, value)
+func (g *gormTX) Or(query string, args ...any) TX {
+	g.clauses = append(g.clauses, gormClause{kind: gormClauseOr, query: query, args: args})
 	return g
 }
 
-func (g *gormTX) Not(field string, value any) TX {
-	g.tx = g.tx.Not(field, value)
+func (g *gormTX) Not(query string, args ...any) TX {
+	g.clauses = append(g.clauses, gormClause{kind: gormClauseNot, query: query, args: args})
+	return g
+}
+
+func (g *gormTX) Select(query string, args ...any) TX {
+	g.clauses = append(g.clauses, gormClause{kind: gormClauseSelect, query: query, args: args})
+	return g
+}
+
+func (g *gormTX) Order(column string, desc bool) TX {
+	g.clauses = append(g.clauses, gormClause{kind: gormClauseOrder, query: column, desc: desc})
 	return g
 }
 
 func (g *gormTX) Limit(limit int) TX {
-	g.tx = g.tx.Limit(limit)
+	g.limit = limit
+	return g
+}
+
+func (g *gormTX) Offset(offset int) TX {
+	g.offset = offset
+	return g
+}
+
+func (g *gormTX) ForUpdate(skipLocked bool) TX {
+	g.locking = &clause.Locking{Strength: "UPDATE"}
+	if skipLocked {
+		g.locking.Options = "SKIP LOCKED"
+	}
+	return g
+}
+
+func (g *gormTX) ForKeyShare() TX {
+	g.locking = &clause.Locking{Strength: "KEY SHARE"}
+	return g
+}
+
+func (g *gormTX) Unscoped() TX {
+	g.unscoped = true
+	return g
+}
+
+func (g *gormTX) RequireMatch() TX {
+	g.requireMatch = true
 	return g
 }
 
 func (g *gormTX) Commit(ctx context.Context) error {
-	return g.tx.Error
+	// do not start a statement for a request that is already gone
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	result := g.statement(ctx)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if g.requireMatch && result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (g *gormTX) Batches(ctx context.Context, size int, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return translateError(g.query(ctx).FindInBatches(g.data, size, func(*gorm.DB, int) error {
+		return fn()
+	}).Error)
+}
+
+// statement builds and executes the recorded query.
+func (g *gormTX) statement(ctx context.Context) *gorm.DB {
+	tx := g.query(ctx)
+	switch g.operation {
+	case gormOperationUpdate:
+		return tx.Updates(g.data)
+	case gormOperationDelete:
+		return tx.Delete(g.data)
+	case gormOperationCount:
+		return tx.Count(g.count)
+	default:
+		return tx.Find(g.data)
+	}
+}
+
+// query applies the recorded clauses to a new statement.
+func (g *gormTX) query(ctx context.Context) *gorm.DB {
+	tx := g.db.WithContext(ctx)
+	if g.unscoped {
+		tx = tx.Unscoped()
+	}
+	tx = tx.Model(g.data)
+	for _, c := range g.clauses {
+		switch c.kind {
+		case gormClauseWhere:
+			tx = tx.Where(c.query, c.args...)
+		case gormClauseOr:
+			tx = tx.Or(c.query, c.args...)
+		case gormClauseNot:
+			tx = tx.Not(c.query, c.args...)
+		case gormClauseSelect:
+			tx = tx.Select(c.query, c.args...)
+		case gormClauseOrder:
+			tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: c.query}, Desc: c.desc})
+		}
+	}
+	if g.limit >= 0 {
+		tx = tx.Limit(g.limit)
+	}
+	if g.offset > 0 {
+		tx = tx.Offset(g.offset)
+	}
+	if g.locking != nil {
+		tx = tx.Clauses(*g.locking)
+	}
+	return tx
 }
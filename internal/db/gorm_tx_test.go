@@ -0,0 +1,395 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type testModel struct {
+	ID   int
+	Name string
+}
+
+type softDeleteModel struct {
+	ID        int
+	Name      string
+	DeletedAt gorm.DeletedAt
+}
+
+// testNow is the current time of test databases, so that timestamps written by gorm are predictable.
+var testNow = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// newDryRunDB returns a gorm.DB that builds statements without sending them to a database.
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{DSN: "postgres://test@localhost:1/test"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		NowFunc:                func() time.Time { return testNow },
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry run database: %v", err)
+	}
+	return gormDB
+}
+
+func TestGormTX_statement(t *testing.T) {
+	tests := []struct {
+		name     string
+		tx       func(db *gorm.DB) *gormTX
+		wantSQL  string
+		wantVars []any
+	}{
+		{
+			name: "find without clauses",
+			tx: func(db *gorm.DB) *gormTX {
+				return newGormTX(db, gormOperationFind, &[]testModel{})
+			},
+			wantSQL:  `SELECT * FROM "test_models"`,
+			wantVars: nil,
+		},
+		{
+			name: "find with where, or, not and limit",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationFind, &[]testModel{})
+				tx.Where("name = ?", "foo").Or("id = ?", 2).Not("id = ?", 3).Limit(10).Offset(20)
+				return tx
+			},
+			wantSQL:  `SELECT * FROM "test_models" WHERE name = $1 OR id = $2 AND NOT id = $3 LIMIT 10 OFFSET 20`,
+			wantVars: []any{"foo", 2, 3},
+		},
+		{
+			name: "where with multiple arguments",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationFind, &[]testModel{})
+				tx.Where("id > ? AND id < ?", 1, 5)
+				return tx
+			},
+			wantSQL:  `SELECT * FROM "test_models" WHERE id > $1 AND id < $2`,
+			wantVars: []any{1, 5},
+		},
+		{
+			name: "find with order",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationFind, &[]testModel{})
+				tx.Order("name", true).Order("id", false)
+				return tx
+			},
+			wantSQL:  `SELECT * FROM "test_models" ORDER BY "name" DESC,"id"`,
+			wantVars: nil,
+		},
+		{
+			name: "find for update skip locked",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationFind, &[]testModel{})
+				tx.Where("name = ?", "foo").Limit(5).ForUpdate(true)
+				return tx
+			},
+			wantSQL:  `SELECT * FROM "test_models" WHERE name = $1 LIMIT 5 FOR UPDATE SKIP LOCKED`,
+			wantVars: []any{"foo"},
+		},
+		{
+			name: "find for key share",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationFind, &[]testModel{})
+				tx.Select("id").Where("id IN ?", []int{1, 2}).ForKeyShare()
+				return tx
+			},
+			wantSQL:  `SELECT "id" FROM "test_models" WHERE id IN ($1,$2) FOR KEY SHARE`,
+			wantVars: []any{1, 2},
+		},
+		{
+			name: "find with select expression",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationFind, &[]testModel{})
+				tx.Select("id, length(name) > ? AS long", 3)
+				return tx
+			},
+			wantSQL:  `SELECT id, length(name) > $1 AS long FROM "test_models"`,
+			wantVars: []any{3},
+		},
+		{
+			name: "update selected columns",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationUpdate, &testModel{})
+				tx.Select("name").Where("id = ?", 1)
+				return tx
+			},
+			wantSQL:  `UPDATE "test_models" SET "name"=$1 WHERE id = $2`,
+			wantVars: []any{"", 1},
+		},
+		{
+			name: "count",
+			tx: func(db *gorm.DB) *gormTX {
+				count := int64(0)
+				tx := newGormTX(db, gormOperationCount, &testModel{})
+				tx.count = &count
+				tx.Where("name = ?", "foo")
+				return tx
+			},
+			wantSQL:  `SELECT count(*) FROM "test_models" WHERE name = $1`,
+			wantVars: []any{"foo"},
+		},
+		{
+			name: "update",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationUpdate, &testModel{Name: "bar"})
+				tx.Where("id = ?", 1)
+				return tx
+			},
+			wantSQL:  `UPDATE "test_models" SET "name"=$1 WHERE id = $2`,
+			wantVars: []any{"bar", 1},
+		},
+		{
+			name: "delete",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationDelete, &testModel{})
+				tx.Where("id = ?", 1)
+				return tx
+			},
+			wantSQL:  `DELETE FROM "test_models" WHERE id = $1`,
+			wantVars: []any{1},
+		},
+		{
+			name: "find excludes soft deleted",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationFind, &[]softDeleteModel{})
+				tx.Where("name = ?", "foo")
+				return tx
+			},
+			wantSQL:  `SELECT * FROM "soft_delete_models" WHERE name = $1 AND "soft_delete_models"."deleted_at" IS NULL`,
+			wantVars: []any{"foo"},
+		},
+		{
+			name: "unscoped find",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationFind, &[]softDeleteModel{})
+				tx.Where("deleted_at IS NOT NULL").Unscoped()
+				return tx
+			},
+			wantSQL:  `SELECT * FROM "soft_delete_models" WHERE deleted_at IS NOT NULL`,
+			wantVars: nil,
+		},
+		{
+			name: "soft delete",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationDelete, &softDeleteModel{})
+				tx.Where("id = ?", 1)
+				return tx
+			},
+			wantSQL:  `UPDATE "soft_delete_models" SET "deleted_at"=$1 WHERE id = $2 AND "soft_delete_models"."deleted_at" IS NULL`,
+			wantVars: []any{testNow, 1},
+		},
+		{
+			name: "unscoped delete",
+			tx: func(db *gorm.DB) *gormTX {
+				tx := newGormTX(db, gormOperationDelete, &softDeleteModel{})
+				tx.Where("id = ?", 1).Unscoped()
+				return tx
+			},
+			wantSQL:  `DELETE FROM "soft_delete_models" WHERE id = $1`,
+			wantVars: []any{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := tt.tx(newDryRunDB(t)).statement(context.Background())
+			if stmt.Error != nil {
+				t.Fatalf("gormTX.statement() error = %v", stmt.Error)
+			}
+			if got := stmt.Statement.SQL.String(); got != tt.wantSQL {
+				t.Errorf("gormTX.statement() SQL = %v, want %v", got, tt.wantSQL)
+			}
+			if diff := cmp.Diff(tt.wantVars, stmt.Statement.Vars); diff != "" {
+				t.Errorf("gormTX.statement() vars = %v", diff)
+			}
+		})
+	}
+}
+
+func TestGormTX_Commit(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{
+			name:    "commit",
+			ctx:     context.Background(),
+			wantErr: nil,
+		},
+		{
+			name:    "canceled context",
+			ctx:     canceled,
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newGormTX(newDryRunDB(t), gormOperationFind, &[]testModel{}).Where("id = ?", 1).Commit(tt.ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("gormTX.Commit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGormTX_Batches(t *testing.T) {
+	repo, fd := newFakeGormRepository(t)
+	fd.results = []fakeResult{
+		{columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(1), "a"}, {int64(2), "b"}}},
+		{columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(3), "c"}}},
+	}
+
+	batch := []testModel{}
+	got := [][]testModel{}
+	err := repo.Find(&batch).Where("name <> ?", "").Batches(context.Background(), 2, func() error {
+		got = append(got, append([]testModel{}, batch...))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("gormTX.Batches() error = %v", err)
+	}
+
+	want := [][]testModel{
+		{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}},
+		{{ID: 3, Name: "c"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("gormTX.Batches() batches = %v", diff)
+	}
+	wantStatements := []string{
+		`SELECT * FROM "test_models" WHERE name <> $1 ORDER BY "test_models"."id" LIMIT 2`,
+		`SELECT * FROM "test_models" WHERE name <> $1 AND "test_models"."id" > $2 ORDER BY "test_models"."id" LIMIT 2`,
+	}
+	if diff := cmp.Diff(wantStatements, fd.Statements()); diff != "" {
+		t.Errorf("gormTX.Batches() statements = %v", diff)
+	}
+}
+
+func TestGormTX_Batches_stop(t *testing.T) {
+	errStop := errors.New("stop")
+	repo, fd := newFakeGormRepository(t)
+	fd.results = []fakeResult{
+		{columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(1), "a"}, {int64(2), "b"}}},
+	}
+
+	err := repo.Find(&[]testModel{}).Batches(context.Background(), 2, func() error {
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("gormTX.Batches() error = %v, want %v", err, errStop)
+	}
+	if got := len(fd.Statements()); got != 1 {
+		t.Errorf("gormTX.Batches() executed %d statements, want 1", got)
+	}
+}
+
+func TestGormTX_lazy(t *testing.T) {
+	gormDB := newDryRunDB(t)
+	executed := 0
+	err := gormDB.Callback().Query().Before("gorm:query").Register("test:count", func(*gorm.DB) {
+		executed++
+	})
+	if err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+
+	repo := &gormRepository{DB: gormDB}
+	tx := repo.Find(&[]testModel{}).Where("id = ?", 1).Limit(1)
+	if executed != 0 {
+		t.Fatalf("Find() executed %d statements before Commit, want 0", executed)
+	}
+	if err := tx.Commit(context.Background()); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if executed != 1 {
+		t.Errorf("Commit() executed %d statements, want 1", executed)
+	}
+}
+
+func TestGormTX_RequireMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		tx       func(repo Repository) TX
+		results  []fakeResult
+		affected []int64
+		wantErr  error
+	}{
+		{
+			name: "find with match",
+			tx: func(repo Repository) TX {
+				return repo.Find(&[]testModel{}).Where("id = ?", 1)
+			},
+			results: []fakeResult{
+				{columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(1), "a"}}},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "find without match",
+			tx: func(repo Repository) TX {
+				return repo.Find(&[]testModel{}).Where("id = ?", 1)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "update with match",
+			tx: func(repo Repository) TX {
+				return repo.Update(&testModel{ID: 1, Name: "a"})
+			},
+			affected: []int64{1},
+			wantErr:  nil,
+		},
+		{
+			name: "update without match",
+			tx: func(repo Repository) TX {
+				return repo.Update(&testModel{ID: 1, Name: "a"})
+			},
+			affected: []int64{0},
+			wantErr:  ErrNotFound,
+		},
+		{
+			name: "delete without match",
+			tx: func(repo Repository) TX {
+				return repo.Delete(&testModel{}).Where("id = ?", 1)
+			},
+			affected: []int64{0},
+			wantErr:  ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, fd := newFakeGormRepository(t)
+			fd.results = tt.results
+			fd.affected = tt.affected
+
+			err := tt.tx(repo).RequireMatch().Commit(context.Background())
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("gormTX.Commit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGormTX_Commit_noMatch(t *testing.T) {
+	repo, fd := newFakeGormRepository(t)
+	fd.affected = []int64{0}
+
+	// statements match no records without error, unless RequireMatch is used
+	err := repo.Delete(&testModel{}).Where("id = ?", 1).Commit(context.Background())
+	if err != nil {
+		t.Errorf("gormTX.Commit() error = %v, want nil", err)
+	}
+}
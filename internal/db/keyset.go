@@ -0,0 +1,179 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidKeyset = errors.New("invalid order")
+)
+
+// OrderField is a column a keyset paginated query is ordered by.
+type OrderField struct {
+	Column string
+	Desc   bool
+}
+
+// Keyset describes the order of a keyset paginated query.
+// The last field must be unique, e.g. the primary key, to make the order stable.
+type Keyset []OrderField
+
+// String returns the keyset in the form "column:direction,column:direction".
+func (k Keyset) String() string {
+	fields := make([]string, 0, len(k))
+	for _, f := range k {
+		direction := "asc"
+		if f.Desc {
+			direction = "desc"
+		}
+		fields = append(fields, f.Column+":"+direction)
+	}
+	return strings.Join(fields, ",")
+}
+
+// ParseKeyset parses a keyset in the form "column:direction,column:direction" as returned by Keyset.String.
+// The direction is either asc or desc and defaults to asc if it is omitted.
+// The columns are not validated, callers have to check them against the columns that may be ordered by.
+func ParseKeyset(s string) (Keyset, error) {
+	keyset := Keyset{}
+	seen := map[string]bool{}
+	for _, field := range strings.Split(s, ",") {
+		column, direction, _ := strings.Cut(strings.TrimSpace(field), ":")
+		if column == "" {
+			return nil, fmt.Errorf("%w: empty column in %q", ErrInvalidKeyset, s)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("%w: column %q is used more than once", ErrInvalidKeyset, column)
+		}
+		seen[column] = true
+
+		f := OrderField{Column: column}
+		switch direction {
+		case "", "asc":
+		case "desc":
+			f.Desc = true
+		default:
+			return nil, fmt.Errorf("%w: unknown direction %q of column %q, expected asc or desc", ErrInvalidKeyset, direction, column)
+		}
+		keyset = append(keyset, f)
+	}
+	return keyset, nil
+}
+
+// Has returns true if the keyset contains column.
+func (k Keyset) Has(column string) bool {
+	for _, f := range k {
+		if f.Column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// Order adds the order clauses of the keyset to tx.
+// If backward is true, the order is reversed.
+func (k Keyset) Order(tx TX, backward bool) TX {
+	for _, f := range k {
+		tx = tx.Order(f.Column, f.Desc != backward)
+	}
+	return tx
+}
+
+// After adds a where clause to tx that selects the records following values in keyset order.
+// If backward is true, the records preceding values are selected instead.
+// values must contain one value per field of the keyset.
+func (k Keyset) After(tx TX, values []any, backward bool) TX {
+	if len(k) == 0 {
+		return tx
+	}
+
+	if k.uniform() {
+		// a row value comparison can be served by a composite index
+		columns := make([]string, 0, len(k))
+		placeholders := make([]string, 0, len(k))
+		for _, f := range k {
+			columns = append(columns, f.Column)
+			placeholders = append(placeholders, "?")
+		}
+		return tx.Where(fmt.Sprintf("(%s) %s (%s)",
+			strings.Join(columns, ", "),
+			k[0].operator(backward),
+			strings.Join(placeholders, ", "),
+		), values...)
+	}
+
+	// (a > ?) OR (a = ? AND b < ?) OR ...
+	conditions := make([]string, 0, len(k))
+	args := []any{}
+	for i, f := range k {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, k[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+		parts = append(parts, f.Column+" "+f.operator(backward)+" ?")
+		args = append(args, values[i])
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+	return tx.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+// uniform returns true if all fields are ordered in the same direction.
+func (k Keyset) uniform() bool {
+	for _, f := range k {
+		if f.Desc != k[0].Desc {
+			return false
+		}
+	}
+	return true
+}
+
+// operator returns the comparison operator that selects the records following a value of the field.
+func (f OrderField) operator(backward bool) string {
+	if f.Desc != backward {
+		return "<"
+	}
+	return ">"
+}
+
+// Cursor is an opaque position in a keyset paginated result.
+type Cursor struct {
+	// Keyset is the string representation of the keyset the cursor was created for.
+	Keyset string `json:"k"`
+	// Values are the keyset values of the record the cursor points at.
+	Values []any `json:"v"`
+	// Backward is true if the cursor points at the page preceding the record.
+	Backward bool `json:"b,omitempty"`
+}
+
+// Encode returns the URL safe string representation of the cursor.
+func (c Cursor) Encode() string {
+	// marshalling a cursor cannot fail, since the values originate from database records
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor created by Cursor.Encode and checks that it belongs to keyset.
+func DecodeCursor(s string, keyset Keyset) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	c := Cursor{}
+	err = json.Unmarshal(data, &c)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if c.Keyset != keyset.String() {
+		return Cursor{}, fmt.Errorf("%w: cursor was created for order %q", ErrInvalidCursor, c.Keyset)
+	}
+	if len(c.Values) != len(keyset) {
+		return Cursor{}, fmt.Errorf("%w: expected %d values, got %d", ErrInvalidCursor, len(keyset), len(c.Values))
+	}
+	return c, nil
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestKeyset_String(t *testing.T) {
+	tests := []struct {
+		name   string
+		keyset Keyset
+		want   string
+	}{
+		{
+			name:   "empty",
+			keyset: Keyset{},
+			want:   "",
+		},
+		{
+			name:   "mixed directions",
+			keyset: Keyset{{Column: "created_at", Desc: true}, {Column: "id"}},
+			want:   "created_at:desc,id:asc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.keyset.String(); got != tt.want {
+				t.Errorf("Keyset.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeyset(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Keyset
+		wantErr error
+	}{
+		{
+			name: "single column",
+			s:    "published_at:desc",
+			want: Keyset{{Column: "published_at", Desc: true}},
+		},
+		{
+			name: "multiple columns",
+			s:    "published_at:desc, title:asc,id",
+			want: Keyset{{Column: "published_at", Desc: true}, {Column: "title"}, {Column: "id"}},
+		},
+		{
+			name: "round trip",
+			s:    Keyset{{Column: "created_at", Desc: true}, {Column: "id"}}.String(),
+			want: Keyset{{Column: "created_at", Desc: true}, {Column: "id"}},
+		},
+		{
+			name:    "empty",
+			s:       "",
+			wantErr: ErrInvalidKeyset,
+		},
+		{
+			name:    "empty column",
+			s:       "title,,id",
+			wantErr: ErrInvalidKeyset,
+		},
+		{
+			name:    "unknown direction",
+			s:       "title:up",
+			wantErr: ErrInvalidKeyset,
+		},
+		{
+			name:    "duplicate column",
+			s:       "title:asc,title:desc",
+			wantErr: ErrInvalidKeyset,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeyset(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseKeyset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseKeyset() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestKeyset_After(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyset   Keyset
+		values   []any
+		backward bool
+		wantSQL  string
+		wantVars []any
+	}{
+		{
+			name:     "uniform descending",
+			keyset:   Keyset{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}},
+			values:   []any{"2023-01-01T00:00:00Z", "b"},
+			wantSQL:  `SELECT * FROM "test_models" WHERE (created_at, id) < ($1, $2) ORDER BY "created_at" DESC,"id" DESC`,
+			wantVars: []any{"2023-01-01T00:00:00Z", "b"},
+		},
+		{
+			name:     "uniform descending backward",
+			keyset:   Keyset{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}},
+			values:   []any{"2023-01-01T00:00:00Z", "b"},
+			backward: true,
+			wantSQL:  `SELECT * FROM "test_models" WHERE (created_at, id) > ($1, $2) ORDER BY "created_at","id"`,
+			wantVars: []any{"2023-01-01T00:00:00Z", "b"},
+		},
+		{
+			name:     "mixed directions",
+			keyset:   Keyset{{Column: "name", Desc: true}, {Column: "id"}},
+			values:   []any{"foo", 1},
+			wantSQL:  `SELECT * FROM "test_models" WHERE ((name < $1) OR (name = $2 AND id > $3)) ORDER BY "name" DESC,"id"`,
+			wantVars: []any{"foo", "foo", 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := newGormTX(newDryRunDB(t), gormOperationFind, &[]testModel{})
+			tt.keyset.Order(tt.keyset.After(tx, tt.values, tt.backward), tt.backward)
+
+			stmt := tx.statement(context.Background())
+			if got := stmt.Statement.SQL.String(); got != tt.wantSQL {
+				t.Errorf("Keyset.After() SQL = %v, want %v", got, tt.wantSQL)
+			}
+			if diff := cmp.Diff(tt.wantVars, stmt.Statement.Vars); diff != "" {
+				t.Errorf("Keyset.After() vars = %v", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	keyset := Keyset{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		want    Cursor
+		wantErr error
+	}{
+		{
+			name: "round trip",
+			cursor: Cursor{
+				Keyset:   keyset.String(),
+				Values:   []any{"2023-01-01T00:00:00Z", "b"},
+				Backward: true,
+			}.Encode(),
+			want: Cursor{
+				Keyset:   keyset.String(),
+				Values:   []any{"2023-01-01T00:00:00Z", "b"},
+				Backward: true,
+			},
+		},
+		{
+			name:    "not base64",
+			cursor:  "!",
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "not json",
+			cursor:  "Zm9v",
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name: "other keyset",
+			cursor: Cursor{
+				Keyset: "title:asc,id:asc",
+				Values: []any{"foo", "b"},
+			}.Encode(),
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name: "missing values",
+			cursor: Cursor{
+				Keyset: keyset.String(),
+				Values: []any{"b"},
+			}.Encode(),
+			wantErr: ErrInvalidCursor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor, keyset)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DecodeCursor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("DecodeCursor() = %v", diff)
+			}
+		})
+	}
+}
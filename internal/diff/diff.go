@@ -0,0 +1,116 @@
+package diff
+
+import "strings"
+
+type Operation string
+
+const (
+	OperationEqual  Operation = "equal"
+	OperationInsert Operation = "insert"
+	OperationDelete Operation = "delete"
+)
+
+// Edit is a single line of a diff.
+type Edit struct {
+	// Operation is the kind of change applied to the line.
+	Operation Operation `json:"op"`
+	// Text is the content of the line without the trailing newline.
+	Text string `json:"text"`
+}
+
+// Lines returns the line based diff that turns a into b.
+// It uses the Myers algorithm, so the diff contains the least number of inserted and deleted lines.
+func Lines(a, b string) []Edit {
+	return diff(splitLines(a), splitLines(b))
+}
+
+// Changed returns true if edits contain any inserted or deleted line.
+func Changed(edits []Edit) bool {
+	for _, e := range edits {
+		if e.Operation != OperationEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines splits s into lines. An empty string has no lines.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func diff(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	max := n + m
+	// v holds the furthest x reached on each diagonal k = x - y, indexed by k + offset
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace holds the relevant part of v before each step, used to recover the path
+	trace := [][]int{}
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int{}, v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			x := 0
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				// move down from diagonal k+1
+				x = v[offset+k+1]
+			} else {
+				// move right from diagonal k-1
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the trace from the end to the start and collects the edits.
+func backtrack(trace [][]int, a, b []string) []Edit {
+	edits := []Edit{}
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d] covers the diagonals -d-1 to d+1
+		at := func(k int) int {
+			return trace[d][k+d+1]
+		}
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, Edit{Operation: OperationEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, Edit{Operation: OperationInsert, Text: b[y-1]})
+			} else {
+				edits = append(edits, Edit{Operation: OperationDelete, Text: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	// the edits were collected in reverse order
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLines(t *testing.T) {
+	type args struct {
+		a string
+		b string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []Edit
+	}{
+		{
+			name: "both empty",
+			args: args{
+				a: "",
+				b: "",
+			},
+			want: []Edit{},
+		},
+		{
+			name: "equal",
+			args: args{
+				a: "foo\nbar",
+				b: "foo\nbar\n",
+			},
+			want: []Edit{
+				{Operation: OperationEqual, Text: "foo"},
+				{Operation: OperationEqual, Text: "bar"},
+			},
+		},
+		{
+			name: "insert into empty",
+			args: args{
+				a: "",
+				b: "foo\nbar",
+			},
+			want: []Edit{
+				{Operation: OperationInsert, Text: "foo"},
+				{Operation: OperationInsert, Text: "bar"},
+			},
+		},
+		{
+			name: "delete everything",
+			args: args{
+				a: "foo\nbar",
+				b: "",
+			},
+			want: []Edit{
+				{Operation: OperationDelete, Text: "foo"},
+				{Operation: OperationDelete, Text: "bar"},
+			},
+		},
+		{
+			name: "replace line",
+			args: args{
+				a: "foo\nbar\nbaz",
+				b: "foo\nqux\nbaz",
+			},
+			want: []Edit{
+				{Operation: OperationEqual, Text: "foo"},
+				{Operation: OperationDelete, Text: "bar"},
+				{Operation: OperationInsert, Text: "qux"},
+				{Operation: OperationEqual, Text: "baz"},
+			},
+		},
+		{
+			name: "myers example",
+			args: args{
+				a: "a\nb\nc\na\nb\nb\na",
+				b: "c\nb\na\nb\na\nc",
+			},
+			want: []Edit{
+				{Operation: OperationDelete, Text: "a"},
+				{Operation: OperationDelete, Text: "b"},
+				{Operation: OperationEqual, Text: "c"},
+				{Operation: OperationInsert, Text: "b"},
+				{Operation: OperationEqual, Text: "a"},
+				{Operation: OperationEqual, Text: "b"},
+				{Operation: OperationDelete, Text: "b"},
+				{Operation: OperationEqual, Text: "a"},
+				{Operation: OperationInsert, Text: "c"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Lines(tt.args.a, tt.args.b)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Lines() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestChanged(t *testing.T) {
+	tests := []struct {
+		name  string
+		edits []Edit
+		want  bool
+	}{
+		{
+			name:  "no edits",
+			edits: nil,
+			want:  false,
+		},
+		{
+			name:  "only equal lines",
+			edits: []Edit{{Operation: OperationEqual, Text: "foo"}},
+			want:  false,
+		},
+		{
+			name:  "inserted line",
+			edits: []Edit{{Operation: OperationEqual, Text: "foo"}, {Operation: OperationInsert, Text: "bar"}},
+			want:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Changed(tt.edits); got != tt.want {
+				t.Errorf("Changed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
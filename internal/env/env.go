@@ -26,3 +26,16 @@ func GetIntEnvOrDefault(key string, def int) int {
 	}
 	return def
 }
+
+// GetBoolEnvOrDefault returns the value of the environment variable key as a bool.
+// If the environment variable is not set or cannot be parsed as a bool, it returns def.
+func GetBoolEnvOrDefault(key string, def bool) bool {
+	if val := os.Getenv(key); val != "" {
+		bval, err := strconv.ParseBool(val)
+		if err != nil {
+			return def
+		}
+		return bval
+	}
+	return def
+}
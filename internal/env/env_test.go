@@ -106,3 +106,59 @@ func TestGetIntEnvOrDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestGetBoolEnvOrDefault(t *testing.T) {
+	type args struct {
+		key string
+		def bool
+	}
+	type testEnv struct {
+		val string
+	}
+	tests := []struct {
+		name string
+		args args
+		env  *testEnv
+		want bool
+	}{
+		{
+			name: "env not set",
+			args: args{
+				key: "TEST",
+				def: true,
+			},
+			env:  nil,
+			want: true,
+		},
+		{
+			name: "env set and valid",
+			args: args{
+				key: "TEST",
+				def: false,
+			},
+			env:  &testEnv{val: "true"},
+			want: true,
+		},
+		{
+			name: "env set and not valid",
+			args: args{
+				key: "TEST",
+				def: true,
+			},
+			env:  &testEnv{val: "asd"},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != nil {
+				os.Setenv(tt.args.key, tt.env.val)
+				defer os.Unsetenv(tt.args.key)
+			}
+
+			if got := GetBoolEnvOrDefault(tt.args.key, tt.args.def); got != tt.want {
+				t.Errorf("GetBoolEnvOrDefault() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+// Package errs defines the errors shared between the storage and the transport layer.
+// Storage implementations wrap their errors in these, servers map them to responses
+// without depending on the storage.
+package errs
+
+import "errors"
+
+var (
+	// ErrNotFound is returned if a requested record does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned if a write conflicts with existing records, e.g. by violating a unique constraint.
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidInput is returned if a value is rejected, e.g. a malformed UUID or a missing required value.
+	ErrInvalidInput = errors.New("invalid input")
+)
@@ -0,0 +1,394 @@
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ContentTypeMergePatch is the media type of a JSON Merge Patch document (RFC 7396).
+	ContentTypeMergePatch = "application/merge-patch+json"
+	// ContentTypeJSONPatch is the media type of a JSON Patch document (RFC 6902).
+	ContentTypeJSONPatch = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is returned if the patch document is malformed.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPathNotFound is returned if a patch operation refers to a location that does not exist.
+	ErrPathNotFound = errors.New("path not found")
+	// ErrTestFailed is returned if a test operation does not match the document.
+	ErrTestFailed = errors.New("test operation failed")
+)
+
+// Operation is a single operation of a JSON Patch document.
+type Operation struct {
+	// Op is the operation to perform: add, remove, replace, move, copy or test.
+	Op string `json:"op"`
+	// Path is the JSON Pointer (RFC 6901) of the target location.
+	Path string `json:"path"`
+	// From is the JSON Pointer of the source location of move and copy operations.
+	From string `json:"from,omitempty"`
+	// Value is the value of add, replace and test operations.
+	// It is empty if the member is missing and the literal null if the value is null.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to doc and returns the patched document.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := unmarshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := unmarshal(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergePatch(target, p))
+}
+
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		// a patch that is not an object replaces the target
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// Apply applies a JSON Patch (RFC 6902) to doc and returns the patched document.
+// The operations are applied in order. If an operation fails, the error is returned and doc is not changed.
+func Apply(doc, patch []byte) ([]byte, error) {
+	operations := []Operation{}
+	err := json.Unmarshal(patch, &operations)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	target, err := unmarshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range operations {
+		target, err = applyOperation(target, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func applyOperation(doc any, op Operation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+		}
+		value, err := unmarshal(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			return replace(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(current, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return add(doc, path, deepCopy(value))
+		}
+		if isProperPrefix(from, path) {
+			return nil, fmt.Errorf("%w: cannot move a value into one of its children", ErrInvalidPatch)
+		}
+		doc, err = remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// add inserts value at path. Array elements at and after the index are shifted.
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(parent any, key string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			p[key] = value
+			return p, nil
+		case []any:
+			if key == "-" {
+				return append(p, value), nil
+			}
+			i, err := arrayIndex(key, len(p)+1)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("%w: %q is not a container", ErrPathNotFound, key)
+		}
+	})
+}
+
+// remove deletes the value at path.
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	return update(doc, path, func(parent any, key string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			if _, ok := p[key]; !ok {
+				return nil, fmt.Errorf("%w: %q", ErrPathNotFound, key)
+			}
+			delete(p, key)
+			return p, nil
+		case []any:
+			i, err := arrayIndex(key, len(p))
+			if err != nil {
+				return nil, err
+			}
+			return append(p[:i], p[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%w: %q is not a container", ErrPathNotFound, key)
+		}
+	})
+}
+
+// replace overwrites the existing value at path.
+func replace(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(parent any, key string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			if _, ok := p[key]; !ok {
+				return nil, fmt.Errorf("%w: %q", ErrPathNotFound, key)
+			}
+			p[key] = value
+			return p, nil
+		case []any:
+			i, err := arrayIndex(key, len(p))
+			if err != nil {
+				return nil, err
+			}
+			p[i] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("%w: %q is not a container", ErrPathNotFound, key)
+		}
+	})
+}
+
+// get returns the value at path.
+func get(doc any, path []string) (any, error) {
+	for _, key := range path {
+		switch d := doc.(type) {
+		case map[string]any:
+			value, ok := d[key]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrPathNotFound, key)
+			}
+			doc = value
+		case []any:
+			i, err := arrayIndex(key, len(d))
+			if err != nil {
+				return nil, err
+			}
+			doc = d[i]
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrPathNotFound, key)
+		}
+	}
+	return doc, nil
+}
+
+// update walks to the parent of the last element of path and replaces it with the result of fn.
+// It returns the updated document.
+func update(doc any, path []string, fn func(parent any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	switch d := doc.(type) {
+	case map[string]any:
+		child, ok := d[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrPathNotFound, path[0])
+		}
+		updated, err := update(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		d[path[0]] = updated
+		return d, nil
+	case []any:
+		i, err := arrayIndex(path[0], len(d))
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(d[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		d[i] = updated
+		return d, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrPathNotFound, path[0])
+	}
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with a slash", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an array index that must be lower than max.
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrPathNotFound, token)
+	}
+	return i, nil
+}
+
+// isProperPrefix returns true if prefix is a parent location of path.
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equal compares two JSON values. Numbers are equal if they have the same numeric value.
+func equal(a, b any) bool {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, val := range av {
+			other, ok := bv[key]
+			if !ok || !equal(val, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		af, aerr := av.Float64()
+		bf, berr := bv.Float64()
+		return aerr == nil && berr == nil && af == bf
+	default:
+		return a == b
+	}
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for key, val := range v {
+			c[key] = deepCopy(val)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, val := range v {
+			c[i] = deepCopy(val)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// unmarshal decodes a JSON document. Numbers are decoded as json.Number to keep their precision.
+func unmarshal(data []byte) (any, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
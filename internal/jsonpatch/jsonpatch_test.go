@@ -0,0 +1,315 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// normalize decodes a JSON document to make documents comparable independent of key order.
+func normalize(t *testing.T, data []byte) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	return v
+}
+
+func TestMergePatch(t *testing.T) {
+	type args struct {
+		doc   string
+		patch string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr error
+	}{
+		{
+			name: "replace value",
+			args: args{
+				doc:   `{"a":"b"}`,
+				patch: `{"a":"c"}`,
+			},
+			want: `{"a":"c"}`,
+		},
+		{
+			name: "add value",
+			args: args{
+				doc:   `{"a":"b"}`,
+				patch: `{"b":"c"}`,
+			},
+			want: `{"a":"b","b":"c"}`,
+		},
+		{
+			name: "remove value",
+			args: args{
+				doc:   `{"a":"b","b":"c"}`,
+				patch: `{"a":null}`,
+			},
+			want: `{"b":"c"}`,
+		},
+		{
+			name: "replace array",
+			args: args{
+				doc:   `{"a":["b"]}`,
+				patch: `{"a":["c","d"]}`,
+			},
+			want: `{"a":["c","d"]}`,
+		},
+		{
+			name: "nested objects",
+			args: args{
+				doc:   `{"a":{"b":"c","d":"e"}}`,
+				patch: `{"a":{"d":null,"f":false}}`,
+			},
+			want: `{"a":{"b":"c","f":false}}`,
+		},
+		{
+			name: "set falsy values",
+			args: args{
+				doc:   `{"published":true,"description":"foo","tags":["a"]}`,
+				patch: `{"published":false,"description":"","tags":[]}`,
+			},
+			want: `{"published":false,"description":"","tags":[]}`,
+		},
+		{
+			name: "invalid patch",
+			args: args{
+				doc:   `{"a":"b"}`,
+				patch: `{"a":`,
+			},
+			wantErr: ErrInvalidPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.args.doc), []byte(tt.args.patch))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MergePatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if diff := cmp.Diff(normalize(t, []byte(tt.want)), normalize(t, got)); diff != "" {
+				t.Errorf("MergePatch() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	type args struct {
+		doc   string
+		patch string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr error
+	}{
+		{
+			name: "add object member",
+			args: args{
+				doc:   `{"foo":"bar"}`,
+				patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+			},
+			want: `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name: "add array element",
+			args: args{
+				doc:   `{"foo":["bar","baz"]}`,
+				patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			},
+			want: `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name: "append array element",
+			args: args{
+				doc:   `{"foo":["bar"]}`,
+				patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			},
+			want: `{"foo":["bar",["abc","def"]]}`,
+		},
+		{
+			name: "remove object member",
+			args: args{
+				doc:   `{"baz":"qux","foo":"bar"}`,
+				patch: `[{"op":"remove","path":"/baz"}]`,
+			},
+			want: `{"foo":"bar"}`,
+		},
+		{
+			name: "remove array element",
+			args: args{
+				doc:   `{"foo":["bar","qux","baz"]}`,
+				patch: `[{"op":"remove","path":"/foo/1"}]`,
+			},
+			want: `{"foo":["bar","baz"]}`,
+		},
+		{
+			name: "replace value",
+			args: args{
+				doc:   `{"baz":"qux","foo":"bar"}`,
+				patch: `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			},
+			want: `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name: "move value",
+			args: args{
+				doc:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+				patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			},
+			want: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name: "move array element",
+			args: args{
+				doc:   `{"foo":["all","grass","cows","eat"]}`,
+				patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			},
+			want: `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name: "copy value",
+			args: args{
+				doc:   `{"foo":{"bar":"baz"}}`,
+				patch: `[{"op":"copy","from":"/foo","path":"/qux"}]`,
+			},
+			want: `{"foo":{"bar":"baz"},"qux":{"bar":"baz"}}`,
+		},
+		{
+			name: "test succeeds",
+			args: args{
+				doc:   `{"baz":"qux","foo":["a",2,"c"]}`,
+				patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`,
+			},
+			want: `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name: "test fails",
+			args: args{
+				doc:   `{"baz":"qux"}`,
+				patch: `[{"op":"test","path":"/baz","value":"bar"}]`,
+			},
+			wantErr: ErrTestFailed,
+		},
+		{
+			name: "escaped pointer",
+			args: args{
+				doc:   `{"a/b":1,"m~n":2}`,
+				patch: `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`,
+			},
+			want: `{"a/b":3}`,
+		},
+		{
+			name: "add to missing parent",
+			args: args{
+				doc:   `{"foo":"bar"}`,
+				patch: `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			},
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name: "replace missing value",
+			args: args{
+				doc:   `{"foo":"bar"}`,
+				patch: `[{"op":"replace","path":"/baz","value":"qux"}]`,
+			},
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name: "array index out of bounds",
+			args: args{
+				doc:   `{"foo":["bar"]}`,
+				patch: `[{"op":"add","path":"/foo/2","value":"qux"}]`,
+			},
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name: "move into child",
+			args: args{
+				doc:   `{"foo":{"bar":"baz"}}`,
+				patch: `[{"op":"move","from":"/foo","path":"/foo/bar/qux"}]`,
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "unknown operation",
+			args: args{
+				doc:   `{"foo":"bar"}`,
+				patch: `[{"op":"merge","path":"/foo","value":"baz"}]`,
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "add null value",
+			args: args{
+				doc:   `{"foo":"bar"}`,
+				patch: `[{"op":"add","path":"/baz","value":null}]`,
+			},
+			want: `{"baz":null,"foo":"bar"}`,
+		},
+		{
+			name: "replace with null value",
+			args: args{
+				doc:   `{"foo":["bar","baz"]}`,
+				patch: `[{"op":"replace","path":"/foo/1","value":null}]`,
+			},
+			want: `{"foo":["bar",null]}`,
+		},
+		{
+			name: "test null value",
+			args: args{
+				doc:   `{"foo":null}`,
+				patch: `[{"op":"test","path":"/foo","value":null}]`,
+			},
+			want: `{"foo":null}`,
+		},
+		{
+			name: "test null value fails",
+			args: args{
+				doc:   `{"foo":"bar"}`,
+				patch: `[{"op":"test","path":"/foo","value":null}]`,
+			},
+			wantErr: ErrTestFailed,
+		},
+		{
+			name: "missing value",
+			args: args{
+				doc:   `{"foo":"bar"}`,
+				patch: `[{"op":"add","path":"/baz"}]`,
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "not an array",
+			args: args{
+				doc:   `{"foo":"bar"}`,
+				patch: `{"op":"add","path":"/baz","value":"qux"}`,
+			},
+			wantErr: ErrInvalidPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.args.doc), []byte(tt.args.patch))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if diff := cmp.Diff(normalize(t, []byte(tt.want)), normalize(t, got)); diff != "" {
+				t.Errorf("Apply() = %v", diff)
+			}
+		})
+	}
+}
@@ -2,9 +2,15 @@ package keystore
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrKeyNotFound is returned by Get if the key does not exist.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
 type KeyStore interface {
 	Geter
 	Seter
@@ -12,6 +18,7 @@ type KeyStore interface {
 }
 
 type Geter interface {
+	// Get returns the value of key. It returns ErrKeyNotFound if the key does not exist or has expired.
 	Get(ctx context.Context, key string) ([]byte, error)
 }
 
@@ -124,7 +124,11 @@ func (rks redisKeyStore) Set(ctx context.Context, key string, value any, expirat
 }
 
 func (rks redisKeyStore) Get(ctx context.Context, key string) ([]byte, error) {
-	return rks.getFunc(ctx, key).Bytes()
+	value, err := rks.getFunc(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	return value, err
 }
 
 func (rks redisKeyStore) Delete(ctx context.Context, key string) error {
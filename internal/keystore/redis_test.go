@@ -2,6 +2,7 @@ package keystore
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -188,6 +189,20 @@ func Test_redisKeyStore_Get(t *testing.T) {
 			},
 			want: []byte("test"),
 		},
+		{
+			name: "missing key",
+			fields: fields{
+				getFunc: func(ctx context.Context, key string) *redis.StringCmd {
+					return redis.NewStringResult("", redis.Nil)
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				key: "test",
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -201,6 +216,9 @@ func Test_redisKeyStore_Get(t *testing.T) {
 				t.Errorf("redisKeyStore.Get() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if err != nil && !errors.Is(err, ErrKeyNotFound) {
+				t.Errorf("redisKeyStore.Get() error = %v, want %v", err, ErrKeyNotFound)
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("redisKeyStore.Get() = %v, want %v", got, tt.want)
 			}
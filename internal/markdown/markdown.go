@@ -0,0 +1,128 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+// md parses GitHub flavored Markdown. Its renderer omits raw HTML and links with
+// potentially dangerous schemes like javascript:, so the rendered HTML is safe to embed.
+var md = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// Document is a parsed Markdown document.
+type Document struct {
+	source []byte
+	root   ast.Node
+}
+
+// Parse parses source as Markdown. Every input is a valid Markdown document.
+func Parse(source string) *Document {
+	src := []byte(source)
+	return &Document{
+		source: src,
+		root:   md.Parser().Parse(text.NewReader(src)),
+	}
+}
+
+// HTML renders the document to HTML.
+func (d *Document) HTML() (string, error) {
+	buf := bytes.Buffer{}
+	err := md.Renderer().Render(&buf, d.source, d.root)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Text returns the plain text of the document without any markup.
+// Blocks are separated by line breaks.
+func (d *Document) Text() string {
+	return plainText(d.root, d.source)
+}
+
+// Excerpt returns the plain text of the first paragraph, shortened to at most maxLength characters.
+// If the document has no paragraph, the beginning of the text is used instead.
+// Shortened excerpts end at a word boundary followed by an ellipsis.
+func (d *Document) Excerpt(maxLength int) string {
+	excerpt := ""
+	ast.Walk(d.root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n.Kind() != ast.KindParagraph {
+			return ast.WalkContinue, nil
+		}
+		excerpt = plainText(n, d.source)
+		if excerpt == "" {
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkStop, nil
+	})
+	if excerpt == "" {
+		excerpt = d.Text()
+	}
+	// an excerpt is a single line
+	return truncate(strings.Join(strings.Fields(excerpt), " "), maxLength)
+}
+
+// WordCount returns the number of words of s.
+func WordCount(s string) int {
+	return len(strings.Fields(s))
+}
+
+// plainText returns the text of node and its descendants.
+func plainText(node ast.Node, source []byte) string {
+	buf := strings.Builder{}
+	// newBlock separates the following text from the text of the previous block
+	newBlock := func() {
+		if buf.Len() > 0 && !strings.HasSuffix(buf.String(), "\n") {
+			buf.WriteByte('\n')
+		}
+	}
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if n.Type() == ast.TypeBlock {
+			newBlock()
+		}
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.HTMLBlock, *ast.RawHTML:
+			// raw HTML is not rendered either
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeBlock, *ast.FencedCodeBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				segment := lines.At(i)
+				buf.Write(segment.Value(source))
+			}
+		case *ast.AutoLink:
+			buf.Write(n.Label(source))
+		case *ast.String:
+			buf.Write(n.Value)
+		case *ast.Text:
+			buf.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(buf.String())
+}
+
+// truncate shortens s to at most maxLength characters, cutting at the last word boundary.
+func truncate(s string, maxLength int) string {
+	if utf8.RuneCountInString(s) <= maxLength {
+		return s
+	}
+	// the last rune is replaced by the ellipsis. If it is a space, the word before it is complete.
+	runes := []rune(s)[:maxLength]
+	if i := strings.LastIndex(string(runes), " "); i > 0 {
+		return string(runes)[:i] + "…"
+	}
+	return string(runes[:maxLength-1]) + "…"
+}
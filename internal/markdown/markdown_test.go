@@ -0,0 +1,194 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDocument_HTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "empty",
+			source: "",
+			want:   "",
+		},
+		{
+			name:   "heading and paragraph",
+			source: "# Title\n\nSome *text*.",
+			want:   "<h1>Title</h1>\n<p>Some <em>text</em>.</p>\n",
+		},
+		{
+			name:   "strikethrough",
+			source: "~~old~~",
+			want:   "<p><del>old</del></p>\n",
+		},
+		{
+			name:   "raw HTML is omitted",
+			source: "<script>alert(1)</script>\n\nfoo <b>bar</b>",
+			want:   "<!-- raw HTML omitted -->\n<p>foo <!-- raw HTML omitted -->bar<!-- raw HTML omitted --></p>\n",
+		},
+		{
+			name:   "dangerous link is dropped",
+			source: "[click](javascript:alert(1))",
+			want:   "<p><a href=\"\">click</a></p>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.source).HTML()
+			if err != nil {
+				t.Fatalf("Document.HTML() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Document.HTML() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDocument_Text(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "empty",
+			source: "",
+			want:   "",
+		},
+		{
+			name:   "inline markup",
+			source: "Some *emphasized* and `code` text with a [link](https://example.com).",
+			want:   "Some emphasized and code text with a link.",
+		},
+		{
+			name:   "blocks",
+			source: "# Title\n\nfirst\nparagraph\n\n- one\n- two\n\n```go\nfunc main() {}\n```",
+			want:   "Title\nfirst paragraph\none\ntwo\nfunc main() {}",
+		},
+		{
+			name:   "raw HTML is omitted",
+			source: "<div>hidden</div>\n\nfoo <b>bar</b>",
+			want:   "foo bar",
+		},
+		{
+			name:   "autolink",
+			source: "see <https://example.com>",
+			want:   "see https://example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.source).Text()
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Document.Text() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDocument_Excerpt(t *testing.T) {
+	type args struct {
+		source    string
+		maxLength int
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "empty",
+			args: args{
+				source:    "",
+				maxLength: 20,
+			},
+			want: "",
+		},
+		{
+			name: "first paragraph",
+			args: args{
+				source:    "# Title\n\nThe *first*\nparagraph.\n\nThe second paragraph.",
+				maxLength: 50,
+			},
+			want: "The first paragraph.",
+		},
+		{
+			name: "without paragraph",
+			args: args{
+				source:    "# Title\n\n- one\n- two",
+				maxLength: 50,
+			},
+			want: "Title one two",
+		},
+		{
+			name: "shortened at word boundary",
+			args: args{
+				source:    "The quick brown fox jumps over the lazy dog.",
+				maxLength: 20,
+			},
+			want: "The quick brown fox…",
+		},
+		{
+			name: "shortened within a long word",
+			args: args{
+				source:    "Donaudampfschifffahrtsgesellschaft",
+				maxLength: 10,
+			},
+			want: "Donaudamp…",
+		},
+		{
+			name: "multi-byte characters",
+			args: args{
+				source:    "Grüße aus Köln und Düsseldorf",
+				maxLength: 16,
+			},
+			want: "Grüße aus Köln…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.args.source).Excerpt(tt.args.maxLength)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Document.Excerpt() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want int
+	}{
+		{
+			name: "empty",
+			s:    "",
+			want: 0,
+		},
+		{
+			name: "whitespace",
+			s:    " \n\t ",
+			want: 0,
+		},
+		{
+			name: "words",
+			s:    "one two\nthree\tfour ",
+			want: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WordCount(tt.s); got != tt.want {
+				t.Errorf("WordCount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -47,9 +47,11 @@ type Client interface {
 type ActionType string
 
 const (
-	ActionTypeCreate ActionType = "create"
-	ActionTypeUpdate ActionType = "update"
-	ActionTypeDelete ActionType = "delete"
+	ActionTypeCreate    ActionType = "create"
+	ActionTypeUpdate    ActionType = "update"
+	ActionTypeDelete    ActionType = "delete"
+	ActionTypePublish   ActionType = "publish"
+	ActionTypeUnpublish ActionType = "unpublish"
 )
 
 type DefaultEvent struct {
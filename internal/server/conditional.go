@@ -0,0 +1,26 @@
+package server
+
+import "strings"
+
+// ETagMatches reports whether the value of an If-Match or If-None-Match header matches etag.
+// The header may contain a comma separated list of entity tags or "*", which matches any etag.
+// If weak is true, the weak comparison of If-None-Match is used, otherwise the strong comparison of If-Match.
+func ETagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+			continue
+		}
+		// weak entity tags never match in a strong comparison
+		if !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
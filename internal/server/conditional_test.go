@@ -0,0 +1,81 @@
+package server
+
+import "testing"
+
+func TestETagMatches(t *testing.T) {
+	type args struct {
+		header string
+		etag   string
+		weak   bool
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "empty header",
+			args: args{
+				header: "",
+				etag:   `"1"`,
+			},
+			want: false,
+		},
+		{
+			name: "wildcard",
+			args: args{
+				header: "*",
+				etag:   `"1"`,
+			},
+			want: true,
+		},
+		{
+			name: "strong match",
+			args: args{
+				header: `"1"`,
+				etag:   `"1"`,
+			},
+			want: true,
+		},
+		{
+			name: "strong mismatch",
+			args: args{
+				header: `"2"`,
+				etag:   `"1"`,
+			},
+			want: false,
+		},
+		{
+			name: "list",
+			args: args{
+				header: `"2", "1"`,
+				etag:   `"1"`,
+			},
+			want: true,
+		},
+		{
+			name: "weak tag in strong comparison",
+			args: args{
+				header: `W/"1"`,
+				etag:   `"1"`,
+			},
+			want: false,
+		},
+		{
+			name: "weak tag in weak comparison",
+			args: args{
+				header: `W/"1"`,
+				etag:   `"1"`,
+				weak:   true,
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ETagMatches(tt.args.header, tt.args.etag, tt.args.weak); got != tt.want {
+				t.Errorf("ETagMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
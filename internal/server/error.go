@@ -1,7 +1,59 @@
 package server
 
+import (
+	"errors"
+	"net/http"
+
+	"github.com/leonsteinhaeuser/example-app/internal/errs"
+)
+
+var (
+	// ErrUnauthenticated is returned if a request requires a user, but does not carry one.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned if the user of a request is not allowed to perform it.
+	ErrForbidden = errors.New("forbidden")
+)
+
 type Error struct {
 	Status  int    `json:"status"`
 	Message string `json:"message"`
 	Error   string `json:"error"`
+	// Details lists the invalid fields of a request that failed validation.
+	Details []FieldError `json:"details,omitempty"`
+}
+
+// StatusCode returns the HTTP status code of a request that failed with err.
+// The shared errors of package errs are mapped to 404 Not Found, 409 Conflict and 400 Bad Request,
+// ErrUnauthenticated to 401 Unauthorized, ErrForbidden to 403 Forbidden,
+// validation errors to 422 Unprocessable Entity and all other errors to 500 Internal Server Error.
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.As(err, &ValidationErrors{}):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// NewError returns the response of a request that failed with err. The status is determined by StatusCode.
+func NewError(message string, err error) Error {
+	status := StatusCode(err)
+	if status == http.StatusUnprocessableEntity {
+		return NewValidationError(message, err)
+	}
+	return Error{
+		Status:  status,
+		Message: message,
+		Error:   err.Error(),
+	}
 }
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/leonsteinhaeuser/example-app/internal/errs"
+)
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "not found",
+			err:  fmt.Errorf("article %w", errs.ErrNotFound),
+			want: http.StatusNotFound,
+		},
+		{
+			name: "conflict",
+			err:  fmt.Errorf("%w: duplicate key", errs.ErrConflict),
+			want: http.StatusConflict,
+		},
+		{
+			name: "invalid input",
+			err:  fmt.Errorf("%w: invalid uuid", errs.ErrInvalidInput),
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "unauthenticated",
+			err:  fmt.Errorf("%w: user required", ErrUnauthenticated),
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "forbidden",
+			err:  fmt.Errorf("%w: not the author", ErrForbidden),
+			want: http.StatusForbidden,
+		},
+		{
+			name: "validation errors",
+			err:  ValidationErrors{{Field: "title", Message: "must not be empty"}},
+			want: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "other",
+			err:  errors.New("connection refused"),
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusCode(tt.err); got != tt.want {
+				t.Errorf("StatusCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Error
+	}{
+		{
+			name: "not found",
+			err:  fmt.Errorf("article %w", errs.ErrNotFound),
+			want: Error{
+				Status:  http.StatusNotFound,
+				Message: "failed to get article",
+				Error:   "article not found",
+			},
+		},
+		{
+			name: "validation errors",
+			err:  ValidationErrors{{Field: "title", Message: "must not be empty"}},
+			want: Error{
+				Status:  http.StatusUnprocessableEntity,
+				Message: "failed to get article",
+				Error:   "validation failed: title must not be empty",
+				Details: []FieldError{{Field: "title", Message: "must not be empty"}},
+			},
+		},
+		{
+			name: "other",
+			err:  errors.New("connection refused"),
+			want: Error{
+				Status:  http.StatusInternalServerError,
+				Message: "failed to get article",
+				Error:   "connection refused",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewError("failed to get article", tt.err)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("NewError() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package middleware
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/netip"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/leonsteinhaeuser/example-app/internal/log"
@@ -10,8 +13,12 @@ import (
 
 const (
 	RequestIDKey contextKey = 0
+	UserIDKey    contextKey = 1
 
 	HeaderRequestID = "X-Request-ID"
+	// HeaderUserID is the header carrying the ID of the user performing the request.
+	// Clients can set it to any value, so it is only read from requests of trusted proxies, see UserID.
+	HeaderUserID = "X-User-ID"
 )
 
 type contextKey int
@@ -45,3 +52,74 @@ func RequestID() func(http.Handler) http.Handler {
 func RequestIDFromContext(ctx context.Context) string {
 	return ctx.Value(RequestIDKey).(string)
 }
+
+// UserID is a middleware that adds the user ID of the HeaderUserID header to the context.
+// The header is only read from requests whose remote address is within trustedProxies, which must be
+// authenticating proxies that set the header for authenticated users and remove it from all other requests.
+// The header of other requests is ignored. Without trusted proxies, no request carries a user ID.
+// Requests with a missing or malformed header are passed on without a user ID.
+// The middleware must run before middlewares replacing the remote address, e.g. RealIP.
+func UserID(trustedProxies ...netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isTrustedProxy(r.RemoteAddr, trustedProxies) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			userID, err := uuid.Parse(r.Header.Get(HeaderUserID))
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// isTrustedProxy returns true if remoteAddr, an address in the form host:port, is within one of trustedProxies.
+func isTrustedProxy(remoteAddr string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr.Addr().Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies parses a comma separated list of IP addresses and networks in CIDR notation,
+// e.g. "10.0.0.0/8,192.168.1.10", as accepted by UserID.
+func ParseTrustedProxies(s string) ([]netip.Prefix, error) {
+	proxies := []netip.Prefix{}
+	for _, proxy := range strings.Split(s, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+			}
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// UserIDFromContext returns the user ID from the context.
+// The second return value is false if the request did not carry a user ID.
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(UserIDKey).(uuid.UUID)
+	return userID, ok
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+func TestUserID(t *testing.T) {
+	userID := uuid.MustParse("2f0c6f2e-9d55-4b47-8d6f-7a2b2c1d7e01")
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+	tests := []struct {
+		name           string
+		trustedProxies []netip.Prefix
+		remoteAddr     string
+		header         string
+		wantUserID     uuid.UUID
+		wantOK         bool
+	}{
+		{
+			name:           "trusted proxy",
+			trustedProxies: trusted,
+			remoteAddr:     "10.1.2.3:51234",
+			header:         userID.String(),
+			wantUserID:     userID,
+			wantOK:         true,
+		},
+		{
+			name:           "trusted IPv6 proxy",
+			trustedProxies: trusted,
+			remoteAddr:     "[::1]:51234",
+			header:         userID.String(),
+			wantUserID:     userID,
+			wantOK:         true,
+		},
+		{
+			name:           "untrusted client",
+			trustedProxies: trusted,
+			remoteAddr:     "192.168.1.10:51234",
+			header:         userID.String(),
+		},
+		{
+			name:       "no trusted proxies",
+			remoteAddr: "10.1.2.3:51234",
+			header:     userID.String(),
+		},
+		{
+			name:           "missing header",
+			trustedProxies: trusted,
+			remoteAddr:     "10.1.2.3:51234",
+		},
+		{
+			name:           "malformed header",
+			trustedProxies: trusted,
+			remoteAddr:     "10.1.2.3:51234",
+			header:         "admin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.header != "" {
+				r.Header.Set(HeaderUserID, tt.header)
+			}
+
+			var gotUserID uuid.UUID
+			var gotOK bool
+			UserID(tt.trustedProxies...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUserID, gotOK = UserIDFromContext(r.Context())
+			})).ServeHTTP(httptest.NewRecorder(), r)
+
+			if gotUserID != tt.wantUserID || gotOK != tt.wantOK {
+				t.Errorf("UserIDFromContext() = %v, %v, want %v, %v", gotUserID, gotOK, tt.wantUserID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []netip.Prefix
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			s:    "",
+			want: []netip.Prefix{},
+		},
+		{
+			name: "addresses and networks",
+			s:    "10.0.0.0/8, 192.168.1.10,,fd00::/8",
+			want: []netip.Prefix{
+				netip.MustParsePrefix("10.0.0.0/8"),
+				netip.MustParsePrefix("192.168.1.10/32"),
+				netip.MustParsePrefix("fd00::/8"),
+			},
+		},
+		{
+			name: "network with host bits",
+			s:    "10.1.2.3/8",
+			want: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		},
+		{
+			name:    "invalid address",
+			s:       "proxy.local",
+			wantErr: true,
+		},
+		{
+			name:    "invalid network",
+			s:       "10.0.0.0/33",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTrustedProxies(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTrustedProxies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.Comparer(func(a, b netip.Prefix) bool { return a == b })); diff != "" {
+				t.Errorf("ParseTrustedProxies() = %v", diff)
+			}
+		})
+	}
+}
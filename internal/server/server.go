@@ -2,6 +2,7 @@ package server
 
 import (
 	"net/http"
+	"net/netip"
 	"strings"
 	"time"
 
@@ -23,14 +24,43 @@ type Server struct {
 	router chi.Router
 }
 
-func NewDefaultServer(logger log.Logger, listen string) *Server {
+// Option configures optional settings of the server.
+type Option func(*options)
+
+type options struct {
+	trustedProxies []netip.Prefix
+}
+
+// WithTrustedProxies reads the ID of the user performing a request from the header middleware.HeaderUserID
+// of requests sent by the given proxies. The proxies must authenticate users, set the header for them and
+// remove it from all other requests. Without trusted proxies, requests do not carry a user ID,
+// since the header could be set by any client.
+func WithTrustedProxies(proxies ...netip.Prefix) Option {
+	return func(o *options) {
+		o.trustedProxies = append(o.trustedProxies, proxies...)
+	}
+}
+
+func NewDefaultServer(logger log.Logger, listen string, opts ...Option) *Server {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	rt := chi.NewRouter()
 	rt.Use(customMiddleware.RequestID())
+	rt.Use(customMiddleware.UserID(o.trustedProxies...))
 	rt.Use(middleware.RealIP)
-	rt.Use(middleware.NoCache)
 	rt.Use(middleware.CleanPath)
 	rt.Use(customMiddleware.LoggerMiddleware(logger))
-	rt.Use(middleware.AllowContentType("application/json"))
+	rt.Use(middleware.AllowContentType(
+		"application/json",
+		"application/merge-patch+json",
+		"application/json-patch+json",
+		"application/x-ndjson",
+		"text/csv",
+		"multipart/form-data",
+	))
 	rt.Use(middleware.Recoverer)
 	return &Server{
 		logger: logger,
@@ -57,6 +87,11 @@ func (s *Server) AddRouter(r Router) {
 	r.Router(s.router)
 }
 
+// ServeHTTP handles a request with the middlewares and routes of the server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
 // Start starts the server
 func (s *Server) Start() error {
 	chi.Walk(s.router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
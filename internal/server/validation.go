@@ -0,0 +1,79 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes why the value of a field is invalid.
+type FieldError struct {
+	// Field is the JSON name of the field. Elements of lists are addressed by index, e.g. "tags[2]".
+	Field string `json:"field"`
+	// Message describes the problem, e.g. "must not be empty".
+	Message string `json:"message"`
+}
+
+// ValidationErrors is the error returned by a failed validation. It lists all invalid fields.
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, fe := range v {
+		msgs = append(msgs, fe.Field+" "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, ", ")
+}
+
+// Validation collects field errors while validating a request payload.
+// The zero value is ready to use.
+//
+//	v := server.Validation{}
+//	v.Required("title", article.Title)
+//	v.Check(article.AuthorID != uuid.Nil, "author_id", "must be set")
+//	return v.Err()
+type Validation struct {
+	errs ValidationErrors
+}
+
+// Check adds a field error with message if ok is false.
+func (v *Validation) Check(ok bool, field, message string) {
+	if !ok {
+		v.errs = append(v.errs, FieldError{Field: field, Message: message})
+	}
+}
+
+// Required adds a field error if value is empty or only consists of white space.
+func (v *Validation) Required(field, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, "must not be empty")
+}
+
+// MaxLength adds a field error if value is longer than max characters.
+func (v *Validation) MaxLength(field, value string, max int) {
+	v.Check(utf8.RuneCountInString(value) <= max, field, "must not be longer than "+strconv.Itoa(max)+" characters")
+}
+
+// Err returns the collected field errors as ValidationErrors, or nil if there are none.
+func (v *Validation) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// NewValidationError returns the 422 Unprocessable Entity response for err.
+// If err is or wraps ValidationErrors, the invalid fields are listed in the details of the response.
+func NewValidationError(message string, err error) Error {
+	e := Error{
+		Status:  http.StatusUnprocessableEntity,
+		Message: message,
+		Error:   err.Error(),
+	}
+	var verrs ValidationErrors
+	if errors.As(err, &verrs) {
+		e.Details = verrs
+	}
+	return e
+}
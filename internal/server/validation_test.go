@@ -0,0 +1,115 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(v *Validation)
+		want     error
+	}{
+		{
+			name:     "no checks",
+			validate: func(v *Validation) {},
+			want:     nil,
+		},
+		{
+			name: "valid",
+			validate: func(v *Validation) {
+				v.Required("title", "foo")
+				v.MaxLength("title", "foo", 3)
+				v.Check(true, "author_id", "must be set")
+			},
+			want: nil,
+		},
+		{
+			name: "required",
+			validate: func(v *Validation) {
+				v.Required("title", " \t")
+			},
+			want: ValidationErrors{{Field: "title", Message: "must not be empty"}},
+		},
+		{
+			name: "max length counts characters",
+			validate: func(v *Validation) {
+				v.MaxLength("title", "äöü", 3)
+				v.MaxLength("description", "abcd", 3)
+			},
+			want: ValidationErrors{{Field: "description", Message: "must not be longer than 3 characters"}},
+		},
+		{
+			name: "multiple errors",
+			validate: func(v *Validation) {
+				v.Required("title", "")
+				v.Check(false, "tags[1]", "must be unique")
+			},
+			want: ValidationErrors{
+				{Field: "title", Message: "must not be empty"},
+				{Field: "tags[1]", Message: "must be unique"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Validation{}
+			tt.validate(v)
+			if diff := cmp.Diff(tt.want, v.Err()); diff != "" {
+				t.Errorf("Validation.Err() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestNewValidationError(t *testing.T) {
+	verrs := ValidationErrors{{Field: "title", Message: "must not be empty"}}
+	tests := []struct {
+		name string
+		err  error
+		want Error
+	}{
+		{
+			name: "validation errors",
+			err:  verrs,
+			want: Error{
+				Status:  http.StatusUnprocessableEntity,
+				Message: "invalid article",
+				Error:   "validation failed: title must not be empty",
+				Details: []FieldError{{Field: "title", Message: "must not be empty"}},
+			},
+		},
+		{
+			name: "wrapped validation errors",
+			err:  fmt.Errorf("patch: %w", verrs),
+			want: Error{
+				Status:  http.StatusUnprocessableEntity,
+				Message: "invalid article",
+				Error:   "patch: validation failed: title must not be empty",
+				Details: []FieldError{{Field: "title", Message: "must not be empty"}},
+			},
+		},
+		{
+			name: "other error",
+			err:  errors.New("failed"),
+			want: Error{
+				Status:  http.StatusUnprocessableEntity,
+				Message: "invalid article",
+				Error:   "failed",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewValidationError("invalid article", tt.err)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("NewValidationError() = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package slug
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// pattern matches slugs as returned by Make.
+	pattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+	// transliterations are replacements of letters whose meaning is lost by removing their diacritics.
+	transliterations = strings.NewReplacer(
+		"ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss",
+		"æ", "ae", "œ", "oe", "ø", "o", "đ", "d", "ł", "l",
+	)
+)
+
+// Make returns a slug of s with at most maxLength characters.
+// A slug consists of lower-case ASCII letters and digits, with words separated by single hyphens.
+// Diacritics are removed, all other characters separate words.
+// The slug is empty if s contains no letters or digits that can be represented in ASCII.
+func Make(s string, maxLength int) string {
+	s = transliterations.Replace(strings.ToLower(s))
+	// decompose letters to remove their diacritics
+	s, _, _ = transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s)
+
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return Truncate(strings.Join(words, "-"), maxLength)
+}
+
+// Truncate shortens the slug s to at most maxLength characters.
+// Whole words are kept if possible.
+func Truncate(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	// the last word is complete if the cut is followed by a hyphen
+	if s[maxLength] == '-' {
+		return s[:maxLength]
+	}
+	s = s[:maxLength]
+	if i := strings.LastIndex(s, "-"); i > 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// Valid returns true if s is a slug.
+func Valid(s string) bool {
+	return pattern.MatchString(s)
+}
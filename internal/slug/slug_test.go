@@ -0,0 +1,182 @@
+package slug
+
+import "testing"
+
+func TestMake(t *testing.T) {
+	type args struct {
+		s         string
+		maxLength int
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "empty",
+			args: args{
+				s:         "",
+				maxLength: 50,
+			},
+			want: "",
+		},
+		{
+			name: "words",
+			args: args{
+				s:         "  Hello, World! 2023  ",
+				maxLength: 50,
+			},
+			want: "hello-world-2023",
+		},
+		{
+			name: "diacritics",
+			args: args{
+				s:         "Crème brûlée à la française",
+				maxLength: 50,
+			},
+			want: "creme-brulee-a-la-francaise",
+		},
+		{
+			name: "transliterations",
+			args: args{
+				s:         "Grüße aus Köln",
+				maxLength: 50,
+			},
+			want: "gruesse-aus-koeln",
+		},
+		{
+			name: "no ASCII letters",
+			args: args{
+				s:         "日本語 !?",
+				maxLength: 50,
+			},
+			want: "",
+		},
+		{
+			name: "truncated",
+			args: args{
+				s:         "The quick brown fox jumps over the lazy dog",
+				maxLength: 20,
+			},
+			want: "the-quick-brown-fox",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Make(tt.args.s, tt.args.maxLength); got != tt.want {
+				t.Errorf("Make() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	type args struct {
+		s         string
+		maxLength int
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "short enough",
+			args: args{
+				s:         "foo-bar",
+				maxLength: 7,
+			},
+			want: "foo-bar",
+		},
+		{
+			name: "cut before hyphen",
+			args: args{
+				s:         "foo-bar-baz",
+				maxLength: 7,
+			},
+			want: "foo-bar",
+		},
+		{
+			name: "cut within word",
+			args: args{
+				s:         "foo-bar-baz",
+				maxLength: 9,
+			},
+			want: "foo-bar",
+		},
+		{
+			name: "cut after hyphen",
+			args: args{
+				s:         "foo-bar-baz",
+				maxLength: 8,
+			},
+			want: "foo-bar",
+		},
+		{
+			name: "single long word",
+			args: args{
+				s:         "foobarbaz",
+				maxLength: 6,
+			},
+			want: "foobar",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Truncate(tt.args.s, tt.args.maxLength); got != tt.want {
+				t.Errorf("Truncate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{
+			name: "valid",
+			s:    "hello-world-2023",
+			want: true,
+		},
+		{
+			name: "empty",
+			s:    "",
+			want: false,
+		},
+		{
+			name: "upper-case",
+			s:    "Hello",
+			want: false,
+		},
+		{
+			name: "double hyphen",
+			s:    "hello--world",
+			want: false,
+		},
+		{
+			name: "leading hyphen",
+			s:    "-hello",
+			want: false,
+		},
+		{
+			name: "trailing hyphen",
+			s:    "hello-",
+			want: false,
+		},
+		{
+			name: "slash",
+			s:    "hello/world",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Valid(tt.s); got != tt.want {
+				t.Errorf("Valid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"net/http"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/leonsteinhaeuser/example-app/internal/env"
 	"github.com/leonsteinhaeuser/example-app/internal/log"
 	"github.com/leonsteinhaeuser/example-app/internal/server"
@@ -17,6 +18,8 @@ var (
 )
 
 func main() {
+	// responses of this service must not be cached
+	httpServer.AddMiddleware(middleware.NoCache)
 	httpServer.AddRouter(v1.NewNumberRouter(logr))
 	httpRouter.AddEndpoint("GET", "/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
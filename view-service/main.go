@@ -6,6 +6,7 @@ import (
 	"html/template"
 	"net/http"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/leonsteinhaeuser/example-app/internal/env"
 	"github.com/leonsteinhaeuser/example-app/internal/log"
 	"github.com/leonsteinhaeuser/example-app/internal/server"
@@ -43,6 +44,8 @@ func init() {
 }
 
 func main() {
+	// responses of this service must not be cached
+	httpServer.AddMiddleware(middleware.NoCache)
 	httpRouter.AddEndpoint("GET", "/", func(w http.ResponseWriter, r *http.Request) {
 		number, err := getNumberFromNumberService(r.Context())
 		if err != nil {